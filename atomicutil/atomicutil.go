@@ -0,0 +1,97 @@
+package atomicutil
+
+import (
+	"sync/atomic"
+)
+
+// Bool is a bool that is safe for concurrent use. The zero value is false.
+type Bool struct {
+	value int32
+}
+
+func (b *Bool) Load() bool {
+	return atomic.LoadInt32(&b.value) != 0
+}
+
+func (b *Bool) Store(value bool) {
+	atomic.StoreInt32(&b.value, boolToInt32(value))
+}
+
+// Swap stores value and returns the previous value
+func (b *Bool) Swap(value bool) bool {
+	return atomic.SwapInt32(&b.value, boolToInt32(value)) != 0
+}
+
+// CompareAndSwap stores new if the current value is old, reporting whether it did
+func (b *Bool) CompareAndSwap(old, new bool) bool {
+	return atomic.CompareAndSwapInt32(&b.value, boolToInt32(old), boolToInt32(new))
+}
+
+func boolToInt32(value bool) int32 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// String is a string that is safe for concurrent use. The zero value is "".
+type String struct {
+	value atomic.Value
+}
+
+func (s *String) Load() string {
+	value, _ := s.value.Load().(string)
+	return value
+}
+
+func (s *String) Store(value string) {
+	s.value.Store(value)
+}
+
+// Swap stores value and returns the previous value
+func (s *String) Swap(value string) string {
+	old, _ := s.value.Swap(value).(string)
+	return old
+}
+
+// CompareAndSwap stores new if the current value is old, reporting whether it did
+func (s *String) CompareAndSwap(old, new string) bool {
+	if old == "" && s.value.CompareAndSwap(nil, new) {
+		return true
+	}
+	return s.value.CompareAndSwap(old, new)
+}
+
+// Value is a type safe wrapper of atomic.Value. The zero value holds the zero
+// value of T.
+type Value[T any] struct {
+	value atomic.Value
+}
+
+// NewValue returns a Value holding value
+func NewValue[T any](value T) *Value[T] {
+	v := new(Value[T])
+	v.Store(value)
+	return v
+}
+
+func (v *Value[T]) Load() T {
+	value, _ := v.value.Load().(holder[T])
+	return value.value
+}
+
+func (v *Value[T]) Store(value T) {
+	v.value.Store(holder[T]{value})
+}
+
+// Swap stores value and returns the previous value
+func (v *Value[T]) Swap(value T) T {
+	old, _ := v.value.Swap(holder[T]{value}).(holder[T])
+	return old.value
+}
+
+// Wraps values so atomic.Value always stores the same concrete type, even
+// when T is an interface type
+type holder[T any] struct {
+	value T
+}
@@ -0,0 +1,53 @@
+package atomicutil
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestBool(t *testing.T) {
+	var b Bool
+	assert.False(t, b.Load())
+	b.Store(true)
+	assert.True(t, b.Load())
+	assert.True(t, b.Swap(false))
+	assert.False(t, b.CompareAndSwap(true, false))
+	assert.True(t, b.CompareAndSwap(false, true))
+	assert.True(t, b.Load())
+}
+
+func TestString(t *testing.T) {
+	var s String
+	assert.Equal(t, "", s.Load())
+	assert.True(t, s.CompareAndSwap("", "first"))
+	assert.False(t, s.CompareAndSwap("", "second"))
+	assert.Equal(t, "first", s.Swap("second"))
+	assert.True(t, s.CompareAndSwap("second", "third"))
+	assert.Equal(t, "third", s.Load())
+}
+
+func TestValue(t *testing.T) {
+	var v Value[map[string]string]
+	assert.Nil(t, v.Load())
+	v.Store(map[string]string{"key": "value"})
+	assert.Equal(t, "value", v.Load()["key"])
+
+	var e Value[error]
+	assert.Nil(t, e.Load())
+	assert.Nil(t, e.Swap(nil))
+
+	n := NewValue(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			n.Store(i)
+			n.Load()
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, n.Load() >= 0 && n.Load() < 10)
+}
@@ -0,0 +1,73 @@
+package benchutil
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Percentiles reported by Percentiles
+var percentiles = map[string]float64{"p50": 50, "p90": 90, "p95": 95, "p99": 99}
+
+// Run calls fn b.N times, spread across workers goroutines. fn receives the
+// iteration number, from 0 to b.N-1. The timer is reset before the workers
+// start, so setup done before calling Run is not measured.
+func Run(b *testing.B, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	var next int64 = -1
+	var wg sync.WaitGroup
+	b.ResetTimer()
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= b.N {
+					return
+				}
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+	b.StopTimer()
+}
+
+// Measure returns how long fn took to run
+func Measure(fn func()) time.Duration {
+	start := time.Now()
+	fn()
+	return time.Since(start)
+}
+
+// Percentiles returns the "p50", "p90", "p95" and "p99" nearest-rank
+// percentiles of samples, or an empty map if there are no samples
+func Percentiles(samples []time.Duration) map[string]time.Duration {
+	result := make(map[string]time.Duration, len(percentiles))
+	if len(samples) == 0 {
+		return result
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for name, p := range percentiles {
+		rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+		if rank < 1 {
+			rank = 1
+		}
+		result[name] = sorted[rank-1]
+	}
+	return result
+}
+
+// ThroughputReport makes the benchmark report MB/s based on bytesPerOp bytes
+// processed per iteration, along with allocations per iteration
+func ThroughputReport(b *testing.B, bytesPerOp int64) {
+	b.SetBytes(bytesPerOp)
+	b.ReportAllocs()
+}
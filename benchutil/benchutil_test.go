@@ -0,0 +1,52 @@
+package benchutil
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestRun(t *testing.T) {
+	var calls, sum int64
+	result := testing.Benchmark(func(b *testing.B) {
+		atomic.StoreInt64(&calls, 0)
+		atomic.StoreInt64(&sum, 0)
+		Run(b, 4, func(i int) {
+			atomic.AddInt64(&calls, 1)
+			atomic.AddInt64(&sum, int64(i))
+		})
+	})
+	n := int64(result.N)
+	assert.Equal(t, n, atomic.LoadInt64(&calls))
+	assert.Equal(t, n*(n-1)/2, atomic.LoadInt64(&sum))
+}
+
+func TestMeasure(t *testing.T) {
+	elapsed := Measure(func() { time.Sleep(10 * time.Millisecond) })
+	assert.True(t, elapsed >= 10*time.Millisecond)
+}
+
+func TestPercentiles(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	result := Percentiles(samples)
+	assert.Equal(t, 50*time.Millisecond, result["p50"])
+	assert.Equal(t, 90*time.Millisecond, result["p90"])
+	assert.Equal(t, 95*time.Millisecond, result["p95"])
+	assert.Equal(t, 99*time.Millisecond, result["p99"])
+	assert.Equal(t, 100*time.Millisecond, samples[0], "samples should not be reordered")
+
+	assert.Equal(t, 0, len(Percentiles(nil)))
+}
+
+func BenchmarkThroughputReport(b *testing.B) {
+	data := make([]byte, 1024)
+	ThroughputReport(b, int64(len(data)))
+	Run(b, 2, func(i int) {
+		_ = append([]byte(nil), data...)
+	})
+}
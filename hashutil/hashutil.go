@@ -0,0 +1,151 @@
+package hashutil
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Number of virtual nodes per node used by ConsistentHash
+const DefaultReplicas int = 100
+
+// SHA256 returns the hex-encoded SHA-256 digest of data
+func SHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// SHA256File returns the hex-encoded SHA-256 digest of the file at path
+func SHA256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// MD5 returns the hex-encoded MD5 digest of data. Not suitable for security
+// purposes, use SHA256 instead.
+func MD5(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CRC32 returns the IEEE CRC-32 checksum of data
+func CRC32(data []byte) uint32 {
+	return crc32.ChecksumIEEE(data)
+}
+
+// ConsistentHash returns the node responsible for key on a hash ring of
+// nodes with DefaultReplicas virtual nodes each. Returns an empty string if
+// nodes is empty.
+func ConsistentHash(nodes []string, key string) string {
+	return NewRing(DefaultReplicas, nodes...).Get(key)
+}
+
+// Ring is a consistent hash ring with a configurable number of virtual nodes
+// per node. Build it once with NewRing when looking up many keys.
+type Ring struct {
+	hashes []uint32
+	nodes  map[uint32]string
+}
+
+func NewRing(replicas int, nodes ...string) *Ring {
+	if replicas < 1 {
+		replicas = 1
+	}
+	ring := &Ring{nodes: make(map[uint32]string, len(nodes)*replicas)}
+	for _, node := range nodes {
+		for i := 0; i < replicas; i++ {
+			hash := CRC32([]byte(strconv.Itoa(i) + node))
+			if _, found := ring.nodes[hash]; !found {
+				ring.hashes = append(ring.hashes, hash)
+			}
+			ring.nodes[hash] = node
+		}
+	}
+	sort.Slice(ring.hashes, func(i, j int) bool { return ring.hashes[i] < ring.hashes[j] })
+	return ring
+}
+
+// Get returns the node responsible for key, or an empty string if the ring
+// has no nodes
+func (ring *Ring) Get(key string) string {
+	if len(ring.hashes) == 0 {
+		return ""
+	}
+	hash := CRC32([]byte(key))
+	i := sort.Search(len(ring.hashes), func(i int) bool { return ring.hashes[i] >= hash })
+	if i == len(ring.hashes) {
+		i = 0
+	}
+	return ring.nodes[ring.hashes[i]]
+}
+
+// BloomFilter is a probabilistic set: Contains never returns false for added
+// data, but may return true for data that was never added
+type BloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// NewBloomFilter returns a BloomFilter sized to hold expectedItems with the
+// given false positive rate (0.0 - 1.0)
+func NewBloomFilter(expectedItems int, falsePositiveRate float64) *BloomFilter {
+	if expectedItems < 1 {
+		expectedItems = 1
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+	n := float64(expectedItems)
+	size := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint64(math.Max(1, math.Round(float64(size)/n*math.Ln2)))
+	return &BloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+// Returns the two base hashes combined for each of the filter's bit positions
+func bloomHashes(data []byte) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write(data)
+	h2 := fnv.New64()
+	h2.Write(data)
+	return h1.Sum64(), h2.Sum64() | 1
+}
+
+func (filter *BloomFilter) Add(data []byte) {
+	h1, h2 := bloomHashes(data)
+	for i := uint64(0); i < filter.hashes; i++ {
+		bit := (h1 + i*h2) % filter.size
+		filter.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (filter *BloomFilter) Contains(data []byte) bool {
+	h1, h2 := bloomHashes(data)
+	for i := uint64(0); i < filter.hashes; i++ {
+		bit := (h1 + i*h2) % filter.size
+		if filter.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package hashutil
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestDigests(t *testing.T) {
+	data := []byte("hello world")
+	assert.Equal(t, "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", SHA256(data))
+	assert.Equal(t, "5eb63bbbe01eeed093cb22bb8f5acdc3", MD5(data))
+	assert.Equal(t, uint32(0x0d4a1185), CRC32(data))
+
+	path := filepath.Join(t.TempDir(), "data")
+	assert.Nil(t, ioutil.WriteFile(path, data, 0666))
+	sum, err := SHA256File(path)
+	assert.Nil(t, err)
+	assert.Equal(t, SHA256(data), sum)
+
+	_, err = SHA256File(filepath.Join(t.TempDir(), "missing"))
+	assert.NotNil(t, err)
+}
+
+func TestConsistentHash(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c"}
+	assert.Equal(t, "", ConsistentHash(nil, "key"))
+	assert.Equal(t, ConsistentHash(nodes, "key"), ConsistentHash(nodes, "key"))
+
+	// Removing a node only moves the keys that node was responsible for
+	before := NewRing(DefaultReplicas, nodes...)
+	after := NewRing(DefaultReplicas, "node-a", "node-b")
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		if node := before.Get(key); node != "node-c" && node != after.Get(key) {
+			moved++
+		}
+	}
+	assert.Equal(t, 0, moved)
+}
+
+func TestBloomFilter(t *testing.T) {
+	filter := NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.Add([]byte("item-" + strconv.Itoa(i)))
+	}
+	for i := 0; i < 1000; i++ {
+		assert.True(t, filter.Contains([]byte("item-"+strconv.Itoa(i))))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.Contains([]byte("other-" + strconv.Itoa(i))) {
+			falsePositives++
+		}
+	}
+	assert.True(t, falsePositives < 300, "false positives: %d", falsePositives)
+}
@@ -0,0 +1,85 @@
+package iputil
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Ranges considered private by IsPrivate
+var privateNets = mustParseCIDRs(
+	"10.0.0.0/8",     // RFC1918
+	"172.16.0.0/12",  // RFC1918
+	"192.168.0.0/16", // RFC1918
+	"127.0.0.0/8",    // IPv4 loopback, RFC1122
+	"169.254.0.0/16", // IPv4 link-local, RFC3927
+	"::1/128",        // IPv6 loopback, RFC4291
+	"fe80::/10",      // IPv6 link-local, RFC4291
+	"fc00::/7",       // IPv6 unique local, RFC4193
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets
+}
+
+// Parse parses an IPv4 or IPv6 address, returning an error if s is not one
+func Parse(s string) (net.IP, error) {
+	ip := net.ParseIP(strings.TrimSpace(s))
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid IP address: %q", s)
+	}
+	return ip, nil
+}
+
+// IsPrivate reports whether ip is in an RFC1918 private range, or is a
+// loopback, link-local or IPv6 unique local address
+func IsPrivate(ip net.IP) bool {
+	for _, ipNet := range privateNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func IsLoopback(ip net.IP) bool {
+	return ip.IsLoopback()
+}
+
+// IsIPv4 reports whether ip is an IPv4 address, including IPv4-mapped IPv6 addresses
+func IsIPv4(ip net.IP) bool {
+	return ip.To4() != nil
+}
+
+func IsIPv6(ip net.IP) bool {
+	return ip.To4() == nil && ip.To16() != nil
+}
+
+// InCIDR reports whether ip is in the network cidr, e.g. "10.0.0.0/8"
+func InCIDR(ip net.IP, cidr string) (bool, error) {
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false, err
+	}
+	return ipNet.Contains(ip), nil
+}
+
+// Anonymize returns a copy of ip with the last octet of an IPv4 address, or
+// the last 80 bits of an IPv6 address, set to zero
+func Anonymize(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(24, 32))
+	}
+	if ip16 := ip.To16(); ip16 != nil {
+		return ip16.Mask(net.CIDRMask(48, 128))
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package iputil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestParse(t *testing.T) {
+	ip, err := Parse(" 192.168.1.1 ")
+	assert.Nil(t, err)
+	assert.True(t, ip.Equal(net.ParseIP("192.168.1.1")))
+
+	_, err = Parse("not an ip")
+	assert.NotNil(t, err)
+	_, err = Parse("256.1.1.1")
+	assert.NotNil(t, err)
+}
+
+func TestIsPrivate(t *testing.T) {
+	private := []string{
+		"10.0.0.0", "10.255.255.255",
+		"172.16.0.0", "172.31.255.255",
+		"192.168.0.0", "192.168.255.255",
+		"127.0.0.1", "169.254.1.1",
+		"::1", "fe80::1", "fd00::1",
+	}
+	for _, s := range private {
+		assert.True(t, IsPrivate(net.ParseIP(s)), s)
+	}
+
+	public := []string{"9.255.255.255", "11.0.0.0", "172.15.255.255", "172.32.0.0", "192.167.255.255", "8.8.8.8", "2001:4860:4860::8888"}
+	for _, s := range public {
+		assert.False(t, IsPrivate(net.ParseIP(s)), s)
+	}
+}
+
+func TestClassification(t *testing.T) {
+	assert.True(t, IsLoopback(net.ParseIP("127.0.0.1")))
+	assert.True(t, IsLoopback(net.ParseIP("::1")))
+	assert.False(t, IsLoopback(net.ParseIP("10.0.0.1")))
+
+	assert.True(t, IsIPv4(net.ParseIP("10.0.0.1")))
+	assert.False(t, IsIPv4(net.ParseIP("::1")))
+	assert.True(t, IsIPv6(net.ParseIP("::1")))
+	assert.False(t, IsIPv6(net.ParseIP("10.0.0.1")))
+}
+
+func TestInCIDR(t *testing.T) {
+	in, err := InCIDR(net.ParseIP("10.1.2.3"), "10.0.0.0/8")
+	assert.Nil(t, err)
+	assert.True(t, in)
+
+	in, err = InCIDR(net.ParseIP("11.1.2.3"), "10.0.0.0/8")
+	assert.Nil(t, err)
+	assert.False(t, in)
+
+	_, err = InCIDR(net.ParseIP("10.1.2.3"), "10.0.0.0")
+	assert.NotNil(t, err)
+}
+
+func TestAnonymize(t *testing.T) {
+	assert.Equal(t, "192.168.1.0", Anonymize(net.ParseIP("192.168.1.123")).String())
+	assert.Equal(t, "2001:db8:85a3::", Anonymize(net.ParseIP("2001:db8:85a3:1234:5678:8a2e:370:7334")).String())
+	assert.Nil(t, Anonymize(nil))
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// Returns a Logger that queues up to queueDepth (at least 1) marshalled
+// entries for a background goroutine to write to the log file given in args (stdout if
+// omitted). Log blocks while the queue is full unless SetDropOnFull is
+// enabled. Close writes all queued entries before closing the file. Entries
+// that fail to be written are counted by FailedEntries.
+func NewAsyncLogger(name string, queueDepth int, args ...string) (*Logger, error) {
+	if queueDepth <= 0 {
+		return nil, fmt.Errorf("Async logger requires a positive queue depth")
+	}
+	file, err := parseArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	logger := newLogger(name, newAsyncWriter(file, queueDepth), file)
+	logger.logStartup()
+	return logger, nil
+}
+
+// Queues lines for a background goroutine to write to dest
+type asyncWriter struct {
+	dest  io.Writer
+	queue chan []byte
+	done  chan struct{}
+
+	// Accessed with the Logger's output lock held
+	closed     bool
+	dropOnFull bool
+	dropped    int64
+
+	// Entries the background goroutine failed to write, accessed atomically
+	failed int64
+}
+
+func newAsyncWriter(dest io.Writer, queueDepth int) *asyncWriter {
+	writer := &asyncWriter{dest: dest, queue: make(chan []byte, queueDepth), done: make(chan struct{})}
+	go writer.run()
+	return writer
+}
+
+// Writes queued lines until the queue is closed and drained
+func (writer *asyncWriter) run() {
+	defer close(writer.done)
+	for line := range writer.queue {
+		if _, err := writer.dest.Write(line); err != nil {
+			atomic.AddInt64(&writer.failed, 1)
+		}
+	}
+}
+
+// Write queues a copy of p, or returns errEntryDropped if the queue is full
+// and dropOnFull is set. Errors writing to the destination are counted in
+// failed.
+func (writer *asyncWriter) Write(p []byte) (int, error) {
+	if writer.closed {
+		return 0, io.ErrClosedPipe
+	}
+	line := append([]byte(nil), p...)
+	if writer.dropOnFull {
+		select {
+		case writer.queue <- line:
+		default:
+			writer.dropped++
+			return 0, errEntryDropped
+		}
+	} else {
+		writer.queue <- line
+	}
+	return len(p), nil
+}
+
+// Close waits for queued lines to be written
+func (writer *asyncWriter) Close() error {
+	if !writer.closed {
+		writer.closed = true
+		close(writer.queue)
+	}
+	<-writer.done
+	return nil
+}
+
+// SetDropOnFull makes an asynchronous Logger (see NewAsyncLogger) drop
+// entries while its queue is full rather than blocking until there is room.
+// Dropped entries are counted by DroppedEntries.
+func (logger *Logger) SetDropOnFull(drop bool) {
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	for _, writer := range logger.out.writers {
+		if async, ok := writer.(*asyncWriter); ok {
+			async.dropOnFull = drop
+		}
+	}
+}
+
+// DroppedEntries returns the number of entries an asynchronous Logger has
+// dropped because its queue was full, see SetDropOnFull
+func (logger *Logger) DroppedEntries() int64 {
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	var dropped int64
+	for _, writer := range logger.out.writers {
+		if async, ok := writer.(*asyncWriter); ok {
+			dropped += async.dropped
+		}
+	}
+	return dropped
+}
+
+// FailedEntries returns the number of queued entries an asynchronous Logger
+// failed to write to its destination
+func (logger *Logger) FailedEntries() int64 {
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	var failed int64
+	for _, writer := range logger.out.writers {
+		if async, ok := writer.(*asyncWriter); ok {
+			failed += atomic.LoadInt64(&async.failed)
+		}
+	}
+	return failed
+}
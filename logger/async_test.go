@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestAsyncLogger(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "async.log")
+
+	for _, queueDepth := range []int{0, -1} {
+		_, err := NewAsyncLogger("test", queueDepth, logPath)
+		assert.NotNil(t, err)
+	}
+
+	logger, err := NewAsyncLogger("test", 16, logPath)
+	assert.Nil(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				assert.Nil(t, logger.Info("async"))
+			}
+		}()
+	}
+	wg.Wait()
+	flushErr, closeErr := logger.Close()
+	assert.Nil(t, flushErr)
+	assert.Nil(t, closeErr)
+
+	// No entries are lost on Close
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 1000, len(withoutStartup(decodeEntries(t, bytes.NewBuffer(raw)))))
+	assert.Equal(t, int64(0), logger.DroppedEntries())
+}
+
+// Writer blocking until released
+type blockingWriter struct {
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (writer *blockingWriter) Write(p []byte) (int, error) {
+	<-writer.release
+	return writer.buf.Write(p)
+}
+
+func TestAsyncLoggerDropOnFull(t *testing.T) {
+	dest := &blockingWriter{release: make(chan struct{})}
+	logger := newLogger("test", newAsyncWriter(dest, 1), nil)
+	logger.SetDropOnFull(true)
+
+	// The first entry is taken by the blocked writer and the second queued,
+	// the rest don't fit
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, logger.Info("dropped"))
+	}
+	close(dest.release)
+	logger.Close()
+	assert.True(t, logger.DroppedEntries() >= 8)
+	assert.Equal(t, int64(10), logger.DroppedEntries()+int64(len(decodeEntries(t, &dest.buf))))
+}
+
+func TestAsyncLoggerFailedEntries(t *testing.T) {
+	logger := newLogger("test", newAsyncWriter(&failingWriter{}, 4), nil)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, logger.Info("failed"))
+	}
+	logger.Close()
+	assert.Equal(t, int64(3), logger.FailedEntries())
+	assert.Equal(t, int64(0), logger.DroppedEntries())
+}
+
+// Logs from roughly 10k goroutines
+func benchmarkConcurrentLog(b *testing.B, logger *Logger) {
+	b.SetParallelism(10000 / runtime.GOMAXPROCS(0))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			logger.Info("benchmark")
+		}
+	})
+	b.StopTimer()
+	logger.Close()
+}
+
+func BenchmarkSyncLogConcurrent(b *testing.B) {
+	benchmarkConcurrentLog(b, newLogger("bench", ioutil.Discard, nil))
+}
+
+func BenchmarkAsyncLogConcurrent(b *testing.B) {
+	benchmarkConcurrentLog(b, newLogger("bench", newAsyncWriter(ioutil.Discard, 1024), nil))
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Periodically flushes an output's buffered writers, see StartAutoFlush
+type autoFlusher struct {
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// StartAutoFlush flushes buffered writers (see NewBufferedLogger) every
+// interval in the background, so entries don't wait in the buffer until
+// Close during quiet periods. The returned function stops flushing, as does
+// Close. Starting again replaces the previous interval, and an interval <= 0
+// stops flushing and returns a no-op function.
+func (logger *Logger) StartAutoFlush(interval time.Duration) func() {
+	var flusher *autoFlusher
+	if interval > 0 {
+		flusher = &autoFlusher{done: make(chan struct{}), stopped: make(chan struct{})}
+	}
+
+	logger.out.lock.Lock()
+	previous := logger.out.autoFlush
+	logger.out.autoFlush = flusher
+	logger.out.lock.Unlock()
+	if previous != nil {
+		previous.stop()
+	}
+	if flusher == nil {
+		return func() {}
+	}
+
+	go func() {
+		defer close(flusher.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				logger.out.flush()
+			case <-flusher.done:
+				return
+			}
+		}
+	}()
+	return flusher.stop
+}
+
+// Stops flushing and waits for the background goroutine to exit. Must not be
+// called with the output lock held.
+func (flusher *autoFlusher) stop() {
+	flusher.once.Do(func() { close(flusher.done) })
+	<-flusher.stopped
+}
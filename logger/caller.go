@@ -0,0 +1,121 @@
+package logger
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Prefixes of the Logger's methods and of the io.Writer adapter used by
+// Write and StdLogger in stack frames, skipped when reporting the caller
+var (
+	loggerMethodPrefix    = reflect.TypeOf(Logger{}).PkgPath() + ".(*Logger)."
+	stdWriterMethodPrefix = reflect.TypeOf(Logger{}).PkgPath() + ".(*stdWriter)."
+)
+
+// Prefix of the standard library log package's functions and methods in stack
+// frames, skipped for entries written through StdLogger
+const stdLogPrefix = "log."
+
+// Reports whether a stack frame belongs to the Logger rather than its caller
+func isLoggerFrame(function string) bool {
+	return strings.HasPrefix(function, loggerMethodPrefix) ||
+		strings.HasPrefix(function, stdWriterMethodPrefix) ||
+		strings.HasPrefix(function, stdLogPrefix)
+}
+
+// Caller of a log method, in bunyan's "src" format
+type source struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Func string `json:"func,omitempty"`
+}
+
+// SetIncludeCaller adds the file, line and function of the code calling the
+// Logger to each log entry as the "src" field. Disabled by default.
+func (logger *Logger) SetIncludeCaller(enabled bool) {
+	logger.includeCaller = enabled
+}
+
+// SetCallerSkip sets the number of stack frames skipped past the Logger's own
+// methods when reporting the caller, for code that always logs through
+// wrapper functions. See WithCallerSkip for a child Logger with extra skips.
+func (logger *Logger) SetCallerSkip(skip int) {
+	logger.callerSkip = skip
+}
+
+// Returns the first frame outside of the Logger's methods and of the standard
+// library log package, skipping a further callerSkip frames for adapters
+// wrapping the Logger
+func (logger *Logger) caller() *source {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	skip := logger.callerSkip
+	inLogger := true
+	for {
+		frame, more := frames.Next()
+		if inLogger && !isLoggerFrame(frame.Function) {
+			inLogger = false
+		}
+		if !inLogger {
+			if skip == 0 {
+				return &source{File: frame.File, Line: frame.Line, Func: frame.Function}
+			}
+			skip--
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// SetCaptureStack adds the call stack to entries at the given levels
+// (ErrorLevel and FatalLevel if none are given) as the "stack" field, an array
+// with one "function (file:line)" string per frame. Disabling it clears the
+// given levels, or all levels if none are given. Disabled by default.
+func (logger *Logger) SetCaptureStack(enabled bool, levels ...int) {
+	captureStack := make(map[int]bool)
+	for level := range logger.captureStack {
+		captureStack[level] = true
+	}
+	if len(levels) == 0 {
+		if !enabled {
+			captureStack = nil
+		}
+		levels = []int{ErrorLevel, FatalLevel}
+	}
+	for _, level := range levels {
+		if enabled {
+			captureStack[level] = true
+		} else {
+			delete(captureStack, level)
+		}
+	}
+	if len(captureStack) == 0 {
+		captureStack = nil
+	}
+	logger.captureStack = captureStack
+}
+
+// Returns the frames of the call stack outside of the Logger's methods
+func stack() []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	var lines []string
+	inLogger := true
+	for {
+		frame, more := frames.Next()
+		if inLogger && !isLoggerFrame(frame.Function) {
+			inLogger = false
+		}
+		if !inLogger {
+			lines = append(lines, frame.Function+" ("+frame.File+":"+strconv.Itoa(frame.Line)+")")
+		}
+		if !more {
+			return lines
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// LogEntry is a bunyan log entry captured by a LogCapture
+type LogEntry struct {
+	Name     string
+	Hostname string
+	Pid      int
+	Level    int
+	Msg      string
+	Time     time.Time
+	V        int
+	Fields   map[string]interface{} // all other fields, as decoded by encoding/json
+}
+
+// LogCapture records the entries written by a Logger created with
+// NewTestLogger, for assertions in tests
+type LogCapture struct {
+	buf  bytes.Buffer
+	lock sync.Mutex
+}
+
+// Returns a Logger writing bunyan entries to the returned LogCapture, without
+// a "Logger initialized" entry
+func NewTestLogger(name string) (*Logger, *LogCapture) {
+	capture := new(LogCapture)
+	return newLogger(name, capture, nil), capture
+}
+
+func (capture *LogCapture) Write(p []byte) (int, error) {
+	capture.lock.Lock()
+	defer capture.lock.Unlock()
+	return capture.buf.Write(p)
+}
+
+// Entries returns every entry written so far, in order. Lines that aren't
+// JSON objects are skipped.
+func (capture *LogCapture) Entries() []LogEntry {
+	capture.lock.Lock()
+	raw := append([]byte(nil), capture.buf.Bytes()...)
+	capture.lock.Unlock()
+
+	var entries []LogEntry
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	scanner.Buffer(nil, len(raw)+1)
+	for scanner.Scan() {
+		fields := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			continue
+		}
+		entries = append(entries, newLogEntry(fields))
+	}
+	return entries
+}
+
+// Moves the standard bunyan fields out of fields into a LogEntry
+func newLogEntry(fields map[string]interface{}) LogEntry {
+	var entry LogEntry
+	entry.Name, _ = fields["name"].(string)
+	entry.Hostname, _ = fields["hostname"].(string)
+	entry.Msg, _ = fields["msg"].(string)
+	entry.Pid = jsonInt(fields["pid"])
+	entry.Level = jsonInt(fields["level"])
+	entry.V = jsonInt(fields["v"])
+	if timestamp, ok := fields["time"].(string); ok {
+		entry.Time, _ = time.Parse(bunyanTimeFormat, timestamp)
+	}
+	for _, field := range []string{"name", "hostname", "msg", "pid", "level", "v", "time"} {
+		delete(fields, field)
+	}
+	entry.Fields = fields
+	return entry
+}
+
+// Returns a decoded JSON number as an int, 0 if it isn't one
+func jsonInt(value interface{}) int {
+	number, _ := value.(float64)
+	return int(number)
+}
+
+// EntriesAtLevel returns the entries written at level
+func (capture *LogCapture) EntriesAtLevel(level int) []LogEntry {
+	var entries []LogEntry
+	for _, entry := range capture.Entries() {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Contains returns whether an entry with message msg was written
+func (capture *LogCapture) Contains(msg string) bool {
+	for _, entry := range capture.Entries() {
+		if entry.Msg == msg {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset discards the entries written so far
+func (capture *LogCapture) Reset() {
+	capture.lock.Lock()
+	defer capture.lock.Unlock()
+	capture.buf.Reset()
+}
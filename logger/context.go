@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// Key of the Logger stored by NewContext
+type contextKey struct{}
+
+// Context keys read by WithContext unless changed with SetContextKeys
+var defaultContextKeys = []interface{}{"request-id", "trace-id", "span-id"}
+
+// NewContext returns a copy of ctx carrying logger, see FromContext
+func NewContext(ctx context.Context, logger *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the Logger stored in ctx by NewContext, or nil if there
+// is none
+func FromContext(ctx context.Context) *Logger {
+	logger, _ := ctx.Value(contextKey{}).(*Logger)
+	return logger
+}
+
+// SetContextKeys sets the context keys whose values WithContext adds to log
+// entries. Defaults to "request-id", "trace-id" and "span-id".
+func (logger *Logger) SetContextKeys(keys ...interface{}) {
+	logger.contextKeys = append([]interface{}(nil), keys...)
+}
+
+// WithContext returns a child Logger (see Child) adding the values of the
+// configured context keys found in ctx to every entry, in fields named after
+// the keys
+func (logger *Logger) WithContext(ctx context.Context) *Logger {
+	fields := make(map[string]interface{})
+	for _, key := range logger.contextKeys {
+		if value := ctx.Value(key); value != nil {
+			fields[fmt.Sprint(key)] = value
+		}
+	}
+	return logger.Child(fields)
+}
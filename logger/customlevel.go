@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// LevelRegistry holds the custom levels registered with RegisterCustomLevel
+type LevelRegistry struct {
+	lock   sync.RWMutex
+	names  map[int]string
+	values map[string]int
+}
+
+// CustomLevels holds every level registered with RegisterCustomLevel
+var CustomLevels = &LevelRegistry{names: make(map[int]string), values: make(map[string]int)}
+
+// RegisterCustomLevel adds a level, such as "audit" at 35, which can be
+// written with LogAtLevel, set with SetLogLevel and is named by LevelName.
+// Returns an error if the name or value is already taken by a built-in or
+// custom level.
+func RegisterCustomLevel(name string, value int) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return fmt.Errorf("Custom log level requires a name")
+	}
+	if _, ok := builtinLevel(name); ok {
+		return fmt.Errorf("Log level name %s is already taken by a built-in level", name)
+	}
+	if builtinName, ok := builtinLevelName(value); ok {
+		return fmt.Errorf("Log level %d is already taken by built-in level %s", value, builtinName)
+	}
+	return CustomLevels.add(name, value)
+}
+
+func (registry *LevelRegistry) add(name string, value int) error {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	if existing, found := registry.names[value]; found {
+		return fmt.Errorf("Log level %d is already registered as %s", value, existing)
+	}
+	if _, found := registry.values[name]; found {
+		return fmt.Errorf("Log level name %s is already registered", name)
+	}
+	registry.names[value] = name
+	registry.values[name] = value
+	return nil
+}
+
+// Removes the custom level value
+func (registry *LevelRegistry) remove(value int) {
+	registry.lock.Lock()
+	defer registry.lock.Unlock()
+	delete(registry.values, registry.names[value])
+	delete(registry.names, value)
+}
+
+// Name returns the name of the custom level value, if registered
+func (registry *LevelRegistry) Name(value int) (string, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	name, found := registry.names[value]
+	return name, found
+}
+
+// Value returns the value of the custom level name, if registered
+func (registry *LevelRegistry) Value(name string) (int, bool) {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	value, found := registry.values[name]
+	return value, found
+}
+
+// Levels returns the names of all custom levels by value
+func (registry *LevelRegistry) Levels() map[int]string {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	levels := make(map[int]string, len(registry.names))
+	for value, name := range registry.names {
+		levels[value] = name
+	}
+	return levels
+}
+
+// LogAtLevel writes a log at level. Built-in levels are filtered by the log
+// level like the level methods; custom levels are always written unless
+// filtering was enabled for them with SetCustomLevelFiltered.
+func (logger *Logger) LogAtLevel(level int, msg string, extras ...interface{}) error {
+	_, custom := CustomLevels.Name(level)
+	if (!custom || logger.filteredCustomLevels[level]) && !logger.IsLevelEnabled(level) {
+		return nil
+	}
+	return logger.Log(msg, level, extras...)
+}
+
+// SetCustomLevelFiltered sets whether LogAtLevel filters entries at the
+// custom level by the log level, like built-in levels. Disabled by default.
+func (logger *Logger) SetCustomLevelFiltered(level int, filtered bool) {
+	filteredCustomLevels := make(map[int]bool, len(logger.filteredCustomLevels)+1)
+	for existing := range logger.filteredCustomLevels {
+		filteredCustomLevels[existing] = true
+	}
+	if filtered {
+		filteredCustomLevels[level] = true
+	} else {
+		delete(filteredCustomLevels, level)
+	}
+	logger.filteredCustomLevels = filteredCustomLevels
+}
@@ -0,0 +1,172 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fields shown in the prefix of pretty printed entries rather than as
+// key=value pairs
+var prettyPrefixFields = map[string]bool{"time": true, "level": true, "name": true, "msg": true, "hostname": true, "pid": true, "v": true}
+
+// SetFieldOrder makes the given fields appear first, in order, in JSON log
+// entries. Remaining fields follow in alphabetical order. Fields missing from
+// an entry are skipped.
+func (logger *Logger) SetFieldOrder(fields []string) {
+	logger.fieldOrder = append([]string(nil), fields...)
+}
+
+// FieldNames overrides the keys of the standard bunyan fields in JSON
+// entries. Empty names keep the default.
+type FieldNames struct {
+	Msg      string // defaults to "msg"
+	Time     string // defaults to "time"
+	Level    string // defaults to "level"
+	Name     string // defaults to "name"
+	Hostname string // defaults to "hostname"
+	Pid      string // defaults to "pid"
+	V        string // defaults to "v"
+}
+
+// SetFieldNames renames the standard bunyan fields in JSON entries, e.g. to
+// "message" and "@timestamp". Field names given to SetFieldOrder must use the
+// new names. Doesn't apply to GELF or pretty printed entries.
+func (logger *Logger) SetFieldNames(names FieldNames) {
+	renamed := make(map[string]string)
+	for field, name := range map[string]string{
+		"msg":      names.Msg,
+		"time":     names.Time,
+		"level":    names.Level,
+		"name":     names.Name,
+		"hostname": names.Hostname,
+		"pid":      names.Pid,
+		"v":        names.V,
+	} {
+		if name != "" && name != field {
+			renamed[field] = name
+		}
+	}
+	if len(renamed) == 0 {
+		renamed = nil
+	}
+	logger.fieldNames = renamed
+}
+
+// Renames the standard bunyan fields of logEntry as set by SetFieldNames
+func (logger *Logger) renameFields(logEntry map[string]interface{}) {
+	values := make(map[string]interface{}, len(logger.fieldNames))
+	for field := range logger.fieldNames {
+		if value, found := logEntry[field]; found {
+			values[field] = value
+			delete(logEntry, field)
+		}
+	}
+	for field, value := range values {
+		logEntry[logger.fieldNames[field]] = value
+	}
+}
+
+// SetPrettyPrint writes entries in a human-readable format for local
+// development instead of JSON:
+//
+//	[2016-01-02T03:04:05.000Z] INFO name: message {key=value ...}
+//
+// Pretty printing takes precedence over the format set by SetFormat.
+func (logger *Logger) SetPrettyPrint(enabled bool) {
+	logger.prettyPrint = enabled
+}
+
+// Marshals a log entry to JSON, honoring the configured field order, or
+// formats it for humans if pretty printing is enabled
+func (logger *Logger) marshal(logEntry map[string]interface{}) ([]byte, error) {
+	if logger.prettyPrint {
+		return formatPretty(logEntry)
+	}
+	if len(logger.fieldOrder) == 0 {
+		return json.Marshal(logEntry)
+	}
+	return marshalOrdered(logEntry, logger.fieldOrder)
+}
+
+// Marshals a map to a JSON object with the fields in order first, followed by
+// the remaining fields sorted alphabetically
+func marshalOrdered(logEntry map[string]interface{}, order []string) ([]byte, error) {
+	fields := make([]string, 0, len(logEntry))
+	seen := make(map[string]bool, len(order))
+	for _, field := range order {
+		if _, found := logEntry[field]; found && !seen[field] {
+			fields = append(fields, field)
+			seen[field] = true
+		}
+	}
+	remaining := make([]string, 0, len(logEntry)-len(fields))
+	for field := range logEntry {
+		if !seen[field] {
+			remaining = append(remaining, field)
+		}
+	}
+	sort.Strings(remaining)
+	fields = append(fields, remaining...)
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(logEntry[field])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Formats a bunyan log entry as "[time] LEVEL name: msg {key=value ...}",
+// with extra fields sorted by key. String values are written as-is, others as
+// JSON.
+func formatPretty(logEntry map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	level, _ := logEntry["level"].(int)
+	fmt.Fprintf(&buf, "[%v] %s %v: %v", logEntry["time"], strings.ToUpper(LevelName(level)), logEntry["name"], logEntry["msg"])
+
+	fields := make([]string, 0, len(logEntry))
+	for field := range logEntry {
+		if !prettyPrefixFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) > 0 {
+		sort.Strings(fields)
+		buf.WriteString(" {")
+		for i, field := range fields {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(field)
+			buf.WriteByte('=')
+			if value, ok := logEntry[field].(string); ok {
+				buf.WriteString(value)
+			} else {
+				value, err := json.Marshal(logEntry[field])
+				if err != nil {
+					return nil, err
+				}
+				buf.Write(value)
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return buf.Bytes(), nil
+}
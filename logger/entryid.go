@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+)
+
+// Buffered readers of crypto/rand, so generating an ID rarely needs a system
+// call and never waits on a shared lock
+var randomReaders = sync.Pool{
+	New: func() interface{} {
+		return bufio.NewReaderSize(rand.Reader, 4096)
+	},
+}
+
+// SetAddEntryID adds a random UUID (version 4) as "id" to every entry, for
+// deduplicating and looking up entries once aggregated. An "id" provided in
+// fixed or extra fields takes precedence.
+func (logger *Logger) SetAddEntryID(enabled bool) {
+	logger.addEntryID = enabled
+}
+
+// Returns a random UUID (version 4) in its canonical string form
+func newEntryID() string {
+	var uuid [16]byte
+	reader := randomReaders.Get().(*bufio.Reader)
+	io.ReadFull(reader, uuid[:]) // crypto/rand does not fail on supported platforms
+	randomReaders.Put(reader)
+	uuid[6] = uuid[6]&0x0f | 0x40 // version 4
+	uuid[8] = uuid[8]&0x3f | 0x80 // RFC 4122 variant
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"io/ioutil"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestSetAddEntryID(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetAddEntryID(true)
+
+	assert.Nil(t, logger.Info("first"))
+	assert.Nil(t, logger.Info("second"))
+	assert.Nil(t, logger.Info("third", map[string]string{"id": "caller-id"}))
+
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 3, len(entries))
+	assert.Regexp(t, uuidV4, entries[0]["id"])
+	assert.Regexp(t, uuidV4, entries[1]["id"])
+	assert.NotEqual(t, entries[0]["id"], entries[1]["id"])
+	assert.Equal(t, "caller-id", entries[2]["id"])
+
+	logger.SetAddEntryID(false)
+	assert.Nil(t, logger.Info("fourth"))
+	entries = decodeEntries(t, buf)
+	assert.Nil(t, entries[len(entries)-1]["id"])
+}
+
+func BenchmarkNewEntryID(b *testing.B) {
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			newEntryID()
+		}
+	})
+}
+
+func BenchmarkLogEntryID(b *testing.B) {
+	logger := newLogger("bench", ioutil.Discard, nil)
+	logger.SetAddEntryID(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("benchmark")
+	}
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LogError writes a log at ErrorLevel with err's message in the "err" field
+// and its type in "errType". Errors wrapped by err are recorded as nested
+// "cause" objects with the same fields. Nothing is logged if err is nil.
+func (logger *Logger) LogError(err error, msg string, extras ...interface{}) error {
+	if err == nil || ErrorLevel < logger.GetLogLevel() {
+		return nil
+	}
+	fields, extrasErr := extrasToFields(extras)
+	if extrasErr != nil {
+		return extrasErr
+	}
+	if fields == nil {
+		fields = make(map[string]interface{})
+	}
+	for field, value := range errorFields(err) {
+		fields[field] = value
+	}
+	return logger.log(msg, ErrorLevel, fields)
+}
+
+// ErrorWithErr is LogError, named to sit alongside the level methods
+func (logger *Logger) ErrorWithErr(err error, msg string, extras ...interface{}) error {
+	return logger.LogError(err, msg, extras...)
+}
+
+// Returns the "err", "errType" and "cause" fields describing err
+func errorFields(err error) map[string]interface{} {
+	fields := map[string]interface{}{
+		"err":     err.Error(),
+		"errType": fmt.Sprintf("%T", err),
+	}
+	if cause := errors.Unwrap(err); cause != nil {
+		fields["cause"] = errorFields(cause)
+	}
+	return fields
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// LogForwarder writes each line read from a source, such as a subprocess's
+// output pipe, as the message of a log entry
+type LogForwarder struct {
+	Name   string
+	source io.Reader
+	dest   *Logger
+	level  int
+	lock   sync.Mutex
+	done   chan struct{}
+	stop   bool
+}
+
+// NewLogForwarder returns a LogForwarder writing lines read from source to
+// dest at level. Forwarded entries include "forwarded": true and the
+// forwarder's name as "forwarder".
+func NewLogForwarder(name string, source io.Reader, dest *Logger, level int) *LogForwarder {
+	return &LogForwarder{Name: name, source: source, dest: dest, level: level}
+}
+
+// Start forwards lines in a background goroutine until source returns EOF or
+// Stop is called
+func (forwarder *LogForwarder) Start() {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+	if forwarder.done != nil {
+		return
+	}
+	forwarder.done = make(chan struct{})
+	go forwarder.run()
+}
+
+// Done returns a channel closed once the forwarder has stopped reading,
+// e.g. after source returns EOF. Start must be called first.
+func (forwarder *LogForwarder) Done() <-chan struct{} {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+	return forwarder.done
+}
+
+// Stop stops forwarding and waits for the forwarding goroutine to exit.
+// Source is closed if it implements io.Closer, otherwise Stop waits for the
+// next line or EOF.
+func (forwarder *LogForwarder) Stop() {
+	forwarder.lock.Lock()
+	done := forwarder.done
+	forwarder.stop = true
+	forwarder.lock.Unlock()
+	if done == nil {
+		return
+	}
+
+	if closer, ok := forwarder.source.(io.Closer); ok {
+		closer.Close()
+	}
+	<-done
+}
+
+func (forwarder *LogForwarder) stopped() bool {
+	forwarder.lock.Lock()
+	defer forwarder.lock.Unlock()
+	return forwarder.stop
+}
+
+func (forwarder *LogForwarder) run() {
+	defer close(forwarder.done)
+
+	scanner := bufio.NewScanner(forwarder.source)
+	for scanner.Scan() {
+		if forwarder.stopped() {
+			return
+		}
+		if forwarder.level >= forwarder.dest.GetLogLevel() {
+			forwarder.dest.log(scanner.Text(), forwarder.level, map[string]interface{}{
+				"forwarded": true,
+				"forwarder": forwarder.Name,
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil && !forwarder.stopped() {
+		forwarder.dest.Error("Error reading forwarded log source: "+err.Error(), map[string]string{"forwarder": forwarder.Name})
+	}
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestLogForwarder(t *testing.T) {
+	dest, buf := newTestBufferLogger("test")
+	cmd := exec.Command("sh", "-c", "echo first line; echo second line")
+	stdout, err := cmd.StdoutPipe()
+	assert.Nil(t, err)
+
+	forwarder := NewLogForwarder("subprocess", stdout, dest, WarnLevel)
+	forwarder.Start()
+	assert.Nil(t, cmd.Start())
+	<-forwarder.Done()
+	assert.Nil(t, cmd.Wait())
+	forwarder.Stop()
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "first line", entries[0]["msg"])
+	assert.Equal(t, "second line", entries[1]["msg"])
+	for _, entry := range entries {
+		assert.Equal(t, float64(WarnLevel), entry["level"])
+		assert.Equal(t, true, entry["forwarded"])
+		assert.Equal(t, "subprocess", entry["forwarder"])
+	}
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"time"
+)
+
+const gelfVersion = "1.1"
+
+// Bunyan fields replaced by GELF's own fields
+var gelfReplacedFields = map[string]bool{
+	"hostname": true,
+	"level":    true,
+	"msg":      true,
+	"time":     true,
+	"v":        true,
+}
+
+// Additional field names for fields whose "_" prefixed name GELF reserves
+var gelfRenamedFields = map[string]string{
+	"id": "_entry_id", // "_id" is not allowed by GELF 1.1
+}
+
+// Maps a Bunyan level to its syslog severity, as used by GELF
+func gelfLevel(level int) int {
+	switch {
+	case level >= FatalLevel:
+		return 0 // Emergency
+	case level >= ErrorLevel:
+		return 3 // Error
+	case level >= WarnLevel:
+		return 4 // Warning
+	case level >= InfoLevel:
+		return 6 // Informational
+	default:
+		return 7 // Debug
+	}
+}
+
+// Converts a Bunyan log entry to a GELF 1.1 message. Fields without a GELF
+// equivalent are sent as additional fields, prefixed with "_", except for "id"
+// which is sent as "_entry_id".
+func gelfEntry(logEntry map[string]interface{}, level int, now time.Time) map[string]interface{} {
+	gelf := map[string]interface{}{
+		"version":       gelfVersion,
+		"host":          logEntry["hostname"],
+		"short_message": logEntry["msg"],
+		"timestamp":     float64(now.UnixNano()/int64(time.Millisecond)) / 1000,
+		"level":         gelfLevel(level),
+	}
+	for field, value := range logEntry {
+		if gelfReplacedFields[field] {
+			continue
+		}
+		if renamed, found := gelfRenamedFields[field]; found {
+			gelf[renamed] = value
+		} else {
+			gelf["_"+field] = value
+		}
+	}
+	return gelf
+}
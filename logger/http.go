@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Defaults for LogHTTPRequest and LogHTTPResponse, see SetHTTPHeaderAllowlist
+// and SetHTTPBodyLimit
+var defaultHTTPHeaderAllowlist = []string{"Accept", "Content-Length", "Content-Type", "User-Agent"}
+
+const defaultHTTPBodyLimit = 1024
+
+// SetHTTPHeaderAllowlist sets the headers logged by LogHTTPRequest and
+// LogHTTPResponse, all other headers are omitted. Defaults to Accept,
+// Content-Length, Content-Type and User-Agent.
+func (logger *Logger) SetHTTPHeaderAllowlist(names ...string) {
+	allowlist := make([]string, 0, len(names))
+	for _, name := range names {
+		allowlist = append(allowlist, http.CanonicalHeaderKey(name))
+	}
+	logger.httpHeaders = allowlist
+}
+
+// SetHTTPBodyLimit sets the number of bytes of request and response bodies
+// logged by LogHTTPRequest and LogHTTPResponse. Defaults to 1024, 0 omits
+// bodies.
+func (logger *Logger) SetHTTPBodyLimit(limit int) {
+	logger.httpBodyLimit = limit
+}
+
+// LogHTTPRequest logs the method, URL, allowed headers and truncated body of
+// r. The body is read and replaced, so r can still be sent afterwards.
+func (logger *Logger) LogHTTPRequest(r *http.Request, level int, extras ...map[string]interface{}) error {
+	if level < logger.GetLogLevel() {
+		return nil
+	}
+	fields := mergeFields(extras)
+	fields["method"] = r.Method
+	fields["url"] = r.URL.String()
+	fields["headers"] = logger.filterHeaders(r.Header)
+	if logger.httpBodyLimit > 0 && r.Body != nil {
+		body, err := peekBody(&r.Body, logger.httpBodyLimit)
+		if err != nil {
+			return err
+		}
+		fields["body"] = body
+	}
+	return logger.log("HTTP request", level, fields)
+}
+
+// LogHTTPResponse logs the status, allowed headers and truncated body of r
+// along with the request's duration. The body is read and replaced, so r can
+// still be consumed afterwards.
+func (logger *Logger) LogHTTPResponse(r *http.Response, level int, durationMs int64, extras ...map[string]interface{}) error {
+	if level < logger.GetLogLevel() {
+		return nil
+	}
+	fields := mergeFields(extras)
+	fields["status"] = r.StatusCode
+	fields["response_headers"] = logger.filterHeaders(r.Header)
+	fields["duration_ms"] = durationMs
+	if logger.httpBodyLimit > 0 && r.Body != nil {
+		body, err := peekBody(&r.Body, logger.httpBodyLimit)
+		if err != nil {
+			return err
+		}
+		fields["response_body"] = body
+	}
+	return logger.log("HTTP response", level, fields)
+}
+
+// Merges extras into a single fields map
+func mergeFields(extras []map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, extra := range extras {
+		for field, value := range extra {
+			fields[field] = value
+		}
+	}
+	return fields
+}
+
+// Returns the allowed headers, joining repeated values with ", "
+func (logger *Logger) filterHeaders(header http.Header) map[string]string {
+	filtered := make(map[string]string)
+	for _, name := range logger.httpHeaders {
+		if values, found := header[name]; found {
+			filtered[name] = strings.Join(values, ", ")
+		}
+	}
+	return filtered
+}
+
+// Reads at most limit bytes of *body and replaces it with a reader returning
+// those bytes followed by the rest of the original body, which it still closes
+func peekBody(body *io.ReadCloser, limit int) (string, error) {
+	original := *body
+	data, err := ioutil.ReadAll(io.LimitReader(original, int64(limit)))
+	*body = &peekedBody{Reader: io.MultiReader(bytes.NewReader(data), original), Closer: original}
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Body replaced by peekBody, closing the original body
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestLogHTTPRequest(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetHTTPHeaderAllowlist("content-type")
+	logger.SetHTTPBodyLimit(5)
+
+	req, _ := http.NewRequest("POST", "http://example.com/users", strings.NewReader(`{"name":"test"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "secret")
+	assert.Nil(t, logger.LogHTTPRequest(req, InfoLevel, map[string]interface{}{"request_id": 7}))
+
+	// The body can still be read after logging
+	body, _ := ioutil.ReadAll(req.Body)
+	assert.Equal(t, `{"name":"test"}`, string(body))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "POST", entries[0]["method"])
+	assert.Equal(t, "http://example.com/users", entries[0]["url"])
+	assert.Equal(t, map[string]interface{}{"Content-Type": "application/json"}, entries[0]["headers"])
+	assert.Equal(t, `{"nam`, entries[0]["body"])
+	assert.Equal(t, float64(7), entries[0]["request_id"])
+}
+
+func TestLogHTTPResponse(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	resp := &http.Response{
+		StatusCode: http.StatusNotFound,
+		Header:     http.Header{"Content-Type": []string{"text/plain"}, "Set-Cookie": []string{"a=b"}},
+		Body:       ioutil.NopCloser(strings.NewReader("not found")),
+	}
+	assert.Nil(t, logger.LogHTTPResponse(resp, WarnLevel, 12))
+
+	logger.SetLogLevel("error")
+	assert.Nil(t, logger.LogHTTPResponse(resp, WarnLevel, 12))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, float64(http.StatusNotFound), entries[0]["status"])
+	assert.Equal(t, map[string]interface{}{"Content-Type": "text/plain"}, entries[0]["response_headers"])
+	assert.Equal(t, "not found", entries[0]["response_body"])
+	assert.Equal(t, float64(12), entries[0]["duration_ms"])
+}
+
+func TestLogHTTPResponseStreaming(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetHTTPBodyLimit(5)
+
+	// Only the logged bytes are read before logging, the stream stays open
+	reader, writer := io.Pipe()
+	go writer.Write([]byte("event"))
+	resp := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: reader}
+	assert.Nil(t, logger.LogHTTPResponse(resp, InfoLevel, 1))
+	assert.Equal(t, "event", decodeEntries(t, buf)[0]["response_body"])
+
+	go func() {
+		writer.Write([]byte(": done"))
+		writer.Close()
+	}()
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "event: done", string(body))
+
+	// Closing the replaced body closes the original
+	assert.Nil(t, resp.Body.Close())
+	_, err = writer.Write([]byte("more"))
+	assert.Equal(t, io.ErrClosedPipe, err)
+}
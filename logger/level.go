@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Request and response body of LogLevelHandler
+type logLevelBody struct {
+	Level string `json:"level"`
+}
+
+// LogLevelHandler returns a handler reporting logger's level as
+// {"level":"<name>"} on GET and changing it on PUT with the same body, for
+// mounting on a path such as /loglevel. Unrecognized levels are rejected with
+// 400 Bad Request.
+func LogLevelHandler(logger *Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var body logLevelBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if _, ok := parseLevel(body.Level); !ok {
+				http.Error(w, "Unrecognized log level: "+body.Level, http.StatusBadRequest)
+				return
+			}
+			logger.SetLogLevel(body.Level)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(logLevelBody{Level: LevelName(logger.GetLogLevel())})
+	}
+}
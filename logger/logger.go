@@ -2,24 +2,62 @@ package logger
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/bottlenose-inc/go-common-tools/atomicutil" // go-common-tools atomicutil package
+	"github.com/prometheus/client_golang/prometheus"       // Official Prometheus golang library
 )
 
 type Logger struct {
-	Name       string
-	Hostname   string
-	Pid        int
-	LogLevel   int
-	file       *os.File
-	writer     io.Writer
-	isBuffered bool
-	lock       sync.Mutex
+	Name                 string
+	Hostname             string
+	Pid                  int
+	logLevel             *atomicutil.Value[int]
+	out                  *output
+	isChild              bool
+	now                  func() time.Time
+	format               string
+	includeCaller        bool
+	captureStack         map[int]bool // levels with stack capture enabled, see SetCaptureStack
+	callerSkip           int
+	fieldOrder           []string
+	prettyPrint          bool
+	encoding             LogEncoding       // see SetEncoding
+	fieldNames           map[string]string // renamed standard fields, see SetFieldNames
+	httpHeaders          []string
+	httpBodyLimit        int
+	entryPool            *sync.Pool
+	fixedFields          map[string]interface{}
+	contextKeys          []interface{}
+	redactedKeys         map[string]bool
+	addEntryID           bool         // see SetAddEntryID
+	filteredCustomLevels map[int]bool // see SetCustomLevelFiltered
+	rateLimiter          *rateLimiter // see SetMessageRateLimit
+	sampling             *atomicutil.Value[*sampling]
+	exitFunc             func(int) // called by FatalAndExit, os.Exit by default
+}
+
+// Log destination, shared by a Logger and its children
+type output struct {
+	file    *os.File
+	path    string      // path file was opened from, see EnableSIGHUPReopen
+	writers []io.Writer // flushed and closed by Close if they implement flusher and io.Closer
+	lock    sync.Mutex
+
+	autoFlush *autoFlusher // set by StartAutoFlush
+
+	// Set by EnableMetrics
+	entriesTotal *prometheus.CounterVec
+	registerer   prometheus.Registerer
+
+	// Set by AttachMetrics, read without the lock
+	attachedMetrics atomicutil.Value[*prometheus.CounterVec]
 }
 
 const (
@@ -32,16 +70,52 @@ const (
 	BunyanSyntaxVersion int = 0
 )
 
-// Returns a fully configured Logger
+const bunyanTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// Output formats supported by SetFormat
+const (
+	FormatBunyan string = "bunyan"
+	FormatGELF   string = "gelf"
+)
+
+// Implemented by buffered writers, flushed when the Logger is closed
+type flusher interface {
+	Flush() error
+}
+
+// Environment variable read by NewLogger and NewBufferedLogger to set the
+// initial log level
+const DefaultLogLevelEnv = "LOG_LEVEL"
+
+// Whether NewLogger and NewBufferedLogger skip the "Logger initialized" entry
+var suppressStartupLog atomicutil.Bool
+
+// Where entries that could not be written are written instead, see
+// writeFallback
+var fallbackWriter io.Writer = os.Stdout
+
+// Returns a fully configured Logger, with its level set from the LOG_LEVEL
+// environment variable if present
 func NewLogger(name string, args ...string) (*Logger, error) {
+	return NewLoggerWithEnvLevel(name, DefaultLogLevelEnv, args...)
+}
+
+// Returns a fully configured Logger, with its level set from the envKey
+// environment variable if present
+func NewLoggerWithEnvLevel(name string, envKey string, args ...string) (*Logger, error) {
 	file, err := parseArgs(args...)
 	if err != nil {
 		return nil, err
 	}
-	return newLogger(name, file, file), nil
+	logger := newLogger(name, file, file)
+	logger.out.path = logPath(args)
+	logger.setLevelFromEnv(envKey)
+	logger.logStartup()
+	return logger, nil
 }
 
-// Returns a fully configured Buffered Logger
+// Returns a fully configured Buffered Logger, with its level set from the
+// LOG_LEVEL environment variable if present
 func NewBufferedLogger(name string, bufSize int, args ...string) (*Logger, error) {
 	file, err := parseArgs(args...)
 	if err != nil {
@@ -49,43 +123,262 @@ func NewBufferedLogger(name string, bufSize int, args ...string) (*Logger, error
 	}
 	writer := bufio.NewWriterSize(file, bufSize)
 	logger := newLogger(name, writer, file)
-	logger.isBuffered = true
+	logger.out.path = logPath(args)
+	logger.setLevelFromEnv(DefaultLogLevelEnv)
+	logger.logStartup()
 	return logger, nil
 }
 
+// Sets the log level from the envKey environment variable if it is set.
+// Unrecognized levels are reported with a warning on stdout and leave the
+// level at TraceLevel.
+func (logger *Logger) setLevelFromEnv(envKey string) {
+	value := strings.TrimSpace(os.Getenv(envKey))
+	if value == "" {
+		return
+	}
+	level, ok := parseLevel(strings.ToLower(value))
+	if !ok {
+		newLogger(logger.Name, os.Stdout, nil).Warning("Unrecognized log level in environment, defaulting to trace", Fields{
+			"env_key": envKey,
+			"value":   value,
+		})
+	}
+	logger.logLevel.Store(level)
+}
+
+// SetSuppressStartupLog controls whether Loggers created afterwards by the
+// New*Logger constructors skip their "Logger initialized" entry. The setting
+// is process-wide, as the entry is written before a Logger is returned.
+func SetSuppressStartupLog(suppress bool) {
+	suppressStartupLog.Store(suppress)
+}
+
+// Writes an Info entry marking the Logger's creation, which makes restarts
+// easy to find when scanning logs
+func (logger *Logger) logStartup() {
+	if suppressStartupLog.Load() || InfoLevel < logger.GetLogLevel() {
+		return
+	}
+	logger.log("Logger initialized", InfoLevel, map[string]interface{}{
+		"logger_name": logger.Name,
+		"pid":         logger.Pid,
+		"hostname":    logger.Hostname,
+		"log_level":   logger.GetLogLevel(),
+	})
+}
+
 // Set LogLevel, only supports the levels defined as consts above
 // Defaults to TraceLevel (all logs will be written)
 func (logger *Logger) SetLogLevel(level string) {
-	switch level {
+	parsed, _ := parseLevel(level)
+	logger.logLevel.Store(parsed)
+}
+
+// ParseLevel returns the level named by name, one of the names returned by
+// LevelName for the levels defined as consts above or registered with
+// RegisterCustomLevel
+func ParseLevel(name string) (int, error) {
+	level, ok := parseLevel(name)
+	if !ok {
+		return 0, fmt.Errorf("Unrecognized log level: %s", name)
+	}
+	return level, nil
+}
+
+// Returns the level named by name, as accepted by SetLogLevel, and whether
+// name was recognized. Unrecognized names return TraceLevel.
+func parseLevel(name string) (int, bool) {
+	if level, ok := builtinLevel(name); ok {
+		return level, true
+	}
+	if level, ok := CustomLevels.Value(name); ok {
+		return level, true
+	}
+	return TraceLevel, false
+}
+
+// Returns the level defined as a const above named by name
+func builtinLevel(name string) (int, bool) {
+	switch name {
 	case "fatal":
-		logger.LogLevel = FatalLevel
+		return FatalLevel, true
 	case "error":
-		logger.LogLevel = ErrorLevel
+		return ErrorLevel, true
 	case "warn":
-		logger.LogLevel = WarnLevel
+		return WarnLevel, true
 	case "info":
-		logger.LogLevel = InfoLevel
+		return InfoLevel, true
 	case "debug":
-		logger.LogLevel = DebugLevel
+		return DebugLevel, true
+	case "trace":
+		return TraceLevel, true
+	default:
+		return 0, false
+	}
+}
+
+// LevelName returns the lowercase name of a level defined as a const above or
+// registered with RegisterCustomLevel, as accepted by SetLogLevel and
+// ParseLevel, or its number for other levels
+func LevelName(level int) string {
+	if name, ok := builtinLevelName(level); ok {
+		return name
+	}
+	if name, ok := CustomLevels.Name(level); ok {
+		return name
+	}
+	return strconv.Itoa(level)
+}
+
+// Returns the name of a level defined as a const above
+func builtinLevelName(level int) (string, bool) {
+	switch level {
+	case FatalLevel:
+		return "fatal", true
+	case ErrorLevel:
+		return "error", true
+	case WarnLevel:
+		return "warn", true
+	case InfoLevel:
+		return "info", true
+	case DebugLevel:
+		return "debug", true
+	case TraceLevel:
+		return "trace", true
 	default:
-		logger.LogLevel = TraceLevel
+		return "", false
 	}
 }
 
+// GetLogLevel returns the current LogLevel, one of the levels defined as consts above
+func (logger *Logger) GetLogLevel() int {
+	return logger.logLevel.Load()
+}
+
+// IsLevelEnabled returns whether entries at level are written by the level
+// methods, to skip building expensive fields for entries that are filtered out
+func (logger *Logger) IsLevelEnabled(level int) bool {
+	return level >= logger.GetLogLevel()
+}
+
 func newLogger(name string, writer io.Writer, file *os.File) *Logger {
 	logger := new(Logger)
 	logger.Name = strings.TrimSpace(name)
 	logger.Hostname, _ = os.Hostname()
 	logger.Pid = os.Getpid()
-	logger.file = file
-	logger.writer = writer
+	logger.logLevel = atomicutil.NewValue(TraceLevel)
+	logger.sampling = atomicutil.NewValue[*sampling](nil)
+	logger.out = &output{file: file, writers: []io.Writer{writer}}
+	logger.now = time.Now
+	logger.exitFunc = os.Exit
+	logger.format = FormatBunyan
+	logger.httpHeaders = defaultHTTPHeaderAllowlist
+	logger.httpBodyLimit = defaultHTTPBodyLimit
+	logger.contextKeys = defaultContextKeys
 	return logger
 }
 
+// SetTimeSource replaces time.Now as the source of log entry timestamps,
+// allowing tests and replay tools to produce reproducible log entries. It also
+// sets the clock used by writers that depend on the time, such as the daily
+// rotating writer.
+func (logger *Logger) SetTimeSource(now func() time.Time) {
+	if now == nil {
+		now = time.Now
+	}
+	logger.now = now
+
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	for _, writer := range logger.out.writers {
+		if clocked, ok := writer.(clockedWriter); ok {
+			clocked.setTimeSource(now)
+		}
+	}
+}
+
+// Implemented by writers that depend on the time, so they follow SetTimeSource
+type clockedWriter interface {
+	setTimeSource(now func() time.Time)
+}
+
+// SetFormat sets the output format of log entries, either FormatBunyan (the
+// default) or FormatGELF
+func (logger *Logger) SetFormat(format string) error {
+	switch format {
+	case FormatBunyan, FormatGELF:
+		logger.format = format
+		return nil
+	default:
+		return fmt.Errorf("Unsupported log format: %s", format)
+	}
+}
+
+// Returns a Logger writing every entry to each of writers. Writers
+// implementing io.Closer, other than stdout and stderr, are closed by Close.
+func NewMultiWriterLogger(name string, writers ...io.Writer) (*Logger, error) {
+	if len(writers) == 0 {
+		return nil, fmt.Errorf("Multi writer logger requires at least one writer")
+	}
+	logger := newLogger(name, writers[0], nil)
+	logger.out.writers = append(logger.out.writers, writers[1:]...)
+	logger.logStartup()
+	return logger, nil
+}
+
+// AddWriter adds a destination that subsequent entries are also written to,
+// shared with the Logger's children. A failing writer doesn't prevent entries
+// from being written to the others, but the first error is returned by Log.
+func (logger *Logger) AddWriter(writer io.Writer) {
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	logger.out.writers = append(logger.out.writers, writer)
+}
+
+// SetOutput replaces the Logger's destination, shared with its children,
+// with writer. Entries being written complete first and buffered writers are
+// flushed, but the previous destination is not closed. Close closes writer if
+// it implements io.Closer.
+func (logger *Logger) SetOutput(writer io.Writer) {
+	logger.out.swap(writer, nil)
+}
+
+// SetFile replaces the Logger's destination, shared with its children, with
+// file, which is closed by Close unless it is stdout. Entries being written
+// complete first and buffered writers are flushed, but the previous
+// destination is not closed.
+func (logger *Logger) SetFile(file *os.File) {
+	logger.out.swap(file, file)
+}
+
+// Flushes the current writers and replaces them with writer and file
+func (out *output) swap(writer io.Writer, file *os.File) {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+	for _, current := range out.writers {
+		if buffered, ok := current.(flusher); ok {
+			buffered.Flush()
+		}
+	}
+	out.writers = []io.Writer{writer}
+	out.file = file
+	out.path = ""
+}
+
+// Returns the log file path given in args, as opened by parseArgs, or an
+// empty string for stdout
+func logPath(args []string) string {
+	if args == nil {
+		return ""
+	}
+	return strings.Replace(strings.TrimSpace(args[0]), "\\", "/", -1)
+}
+
 // Returns os.File based on args
 func parseArgs(args ...string) (*os.File, error) {
 	if args != nil { // We only care about args[0], but using ...string allows args to be omitted
-		path := strings.Replace(strings.TrimSpace(args[0]), "\\", "/", -1)
+		path := logPath(args)
 		// Creates path to log file if it does not already exist
 		if strings.Contains(path, "/") {
 			if err := os.MkdirAll(path[0:strings.LastIndex(path, "/")], 0777); err != nil {
@@ -99,108 +392,364 @@ func parseArgs(args ...string) (*os.File, error) {
 	}
 }
 
+// Returns a copy of logger sharing its destination. Closing the copy is a no-op.
+func (logger *Logger) child() *Logger {
+	child := *logger
+	child.logLevel = atomicutil.NewValue(logger.GetLogLevel())
+	child.sampling = atomicutil.NewValue(logger.sampling.Load())
+	child.isChild = true
+	return &child
+}
+
+// Child returns a Logger sharing this Logger's destination and configuration
+// that adds fixedExtras to every entry, for example a request id. Fields
+// passed when logging take precedence over fixedExtras. The child starts
+// with this Logger's level but is unaffected by later changes to it.
+func (logger *Logger) Child(fixedExtras map[string]interface{}) *Logger {
+	child := logger.child()
+	child.fixedFields = make(map[string]interface{}, len(logger.fixedFields)+len(fixedExtras))
+	for field, value := range logger.fixedFields {
+		child.fixedFields[field] = value
+	}
+	for field, value := range fixedExtras {
+		child.fixedFields[field] = value
+	}
+	return child
+}
+
+// WithCallerSkip returns a child Logger that skips additional stack frames
+// when reporting the caller (see SetIncludeCaller), for use by adapters that
+// wrap the Logger's methods
+func (logger *Logger) WithCallerSkip(additional int) *Logger {
+	child := logger.child()
+	child.callerSkip += additional
+	return child
+}
+
 // Required for expected output if using a Buffered Logger, recommended otherwise
+// Closing a child Logger is a no-op, only the Logger it was derived from closes the destination
 func (logger *Logger) Close() (flushErr error, closeErr error) {
-	// Protect access to writer & file
-	logger.lock.Lock()
-	defer logger.lock.Unlock()
+	if logger.isChild {
+		return nil, nil
+	}
 
-	// Flush buffer (if buffered logger) and close file
-	if logger.isBuffered {
-		flushErr = logger.writer.(*bufio.Writer).Flush()
+	// Stop the rate limiter and auto flushing first, as they take the lock
+	if logger.rateLimiter != nil {
+		logger.rateLimiter.stop()
+		logger.rateLimiter = nil
+	}
+	logger.out.lock.Lock()
+	autoFlush := logger.out.autoFlush
+	logger.out.autoFlush = nil
+	logger.out.lock.Unlock()
+	if autoFlush != nil {
+		autoFlush.stop()
 	}
-	if logger.file != os.Stdout {
-		closeErr = logger.file.Close()
+
+	// Protect access to writers & file
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+
+	// Flush buffers (if buffered logger), close writers (other than the file and
+	// standard streams) and close file, returning the first error of each kind
+	for _, writer := range logger.out.writers {
+		if buffered, ok := writer.(flusher); ok {
+			if err := buffered.Flush(); flushErr == nil {
+				flushErr = err
+			}
+		}
+		if closer, ok := writer.(io.Closer); ok && !isStandardStream(writer) && writer != io.Writer(logger.out.file) {
+			if err := closer.Close(); closeErr == nil {
+				closeErr = err
+			}
+		}
+	}
+	if logger.out.file != nil && logger.out.file != os.Stdout {
+		if err := logger.out.file.Close(); closeErr == nil {
+			closeErr = err
+		}
 	}
 	return flushErr, closeErr
 }
 
-// Log outputs a JSON-ified log to the configured destination
-func (logger *Logger) Log(msg string, level int, extras ...map[string]string) error {
-	// Create initial log entry map
-	logEntry := map[string]interface{}{
-		"hostname": logger.Hostname,
-		"level":    level,
-		"msg":      msg,
-		"name":     logger.Name,
-		"pid":      logger.Pid,
-		"time":     strings.Replace(time.Now().String()[:23], " ", "T", 1) + "Z", // time in bunyan's format
-		"v":        BunyanSyntaxVersion,
-	}
-
-	// Add extras to log entry if provided
-	if extras != nil {
-		for _, extra := range extras {
+// Fields holds the extra fields of a log entry. Values may be of any type
+// that can be marshalled to JSON, nil values are written as null.
+type Fields = map[string]interface{}
+
+// Log outputs a JSON-ified log to the configured destination. Each of extras
+// must be a Fields (map[string]interface{}) or, for compatibility, a
+// map[string]string; other types are rejected with an error.
+func (logger *Logger) Log(msg string, level int, extras ...interface{}) error {
+	fields, err := extrasToFields(extras)
+	if err != nil {
+		return err
+	}
+	return logger.log(msg, level, fields)
+}
+
+// Merges extras passed to Log into a single map, nil if there are none
+func extrasToFields(extras []interface{}) (map[string]interface{}, error) {
+	if extras == nil {
+		return nil, nil
+	}
+	fields := make(map[string]interface{})
+	for _, extra := range extras {
+		switch extra := extra.(type) {
+		case nil:
+		case map[string]interface{}:
 			for field, value := range extra {
-				logEntry[field] = value
+				fields[field] = value
 			}
+		case map[string]string:
+			for field, value := range extra {
+				fields[field] = value
+			}
+		default:
+			return nil, fmt.Errorf("Unsupported log extras type: %T", extra)
 		}
 	}
+	return fields, nil
+}
 
-	// Protect access to writer
-	logger.lock.Lock()
-	defer logger.lock.Unlock()
+// Builds a log entry with the standard bunyan fields plus fields and writes it
+func (logger *Logger) log(msg string, level int, fields map[string]interface{}) error {
+	if !logger.sampled(level) || (logger.rateLimiter != nil && !logger.rateLimiter.allow(msg)) {
+		logger.out.countAttached(droppedLabel)
+		return nil
+	}
+	now := logger.now()
 
-	// Marshal log entry to JSON, or log error
-	if logJson, err := json.Marshal(logEntry); err != nil {
-		io.WriteString(logger.writer, fmt.Sprintf("Error marshalling log entry JSON: %s", err.Error()))
-		return err
+	// Create initial log entry map
+	logEntry := logger.newEntry()
+	defer logger.releaseEntry(logEntry)
+	logEntry["hostname"] = logger.Hostname
+	logEntry["level"] = level
+	logEntry["msg"] = msg
+	logEntry["name"] = logger.Name
+	logEntry["pid"] = logger.Pid
+	logEntry["time"] = now.Format(bunyanTimeFormat) // time in bunyan's format
+	logEntry["v"] = BunyanSyntaxVersion
+	if logger.addEntryID {
+		logEntry["id"] = newEntryID()
+	}
+
+	// Add fixed and extra fields to log entry if provided
+	for field, value := range logger.fixedFields {
+		logEntry[field] = value
+	}
+	for field, value := range fields {
+		logEntry[field] = value
+	}
+	if logger.redactedKeys != nil {
+		logger.redact(logEntry)
+	}
+
+	if logger.includeCaller {
+		logEntry["src"] = logger.caller()
+	}
+	if logger.captureStack[level] {
+		logEntry["stack"] = stack()
+	}
+
+	if !logger.prettyPrint {
+		if logger.format == FormatGELF {
+			logEntry = gelfEntry(logEntry, level, now)
+		} else if logger.fieldNames != nil {
+			logger.renameFields(logEntry)
+		}
+	}
+
+	// Marshal log entry to JSON or msgpack before taking the lock
+	encoded, err := logger.encode(logEntry)
+
+	// Protect access to writers
+	logger.out.lock.Lock()
+	if err != nil {
+		logger.out.write(logger.encodeError(err), ErrorLevel)
 	} else {
 		// Write log entry
-		_, err := io.WriteString(logger.writer, string(logJson)+"\n")
-		if err != nil {
-			logger.writer = os.Stdout
-			logger.Error(fmt.Sprintf("Error writing to log: %s", err.Error()))
-			return err
+		err = logger.out.write(string(encoded), level)
+	}
+	entriesTotal := logger.out.entriesTotal
+	fallBack := err != nil && err != errEntryDropped && encoded != nil && logger.out.fallsBack()
+	logger.out.lock.Unlock()
+
+	// Metrics are recorded without the lock in case counters log
+	switch {
+	case err == errEntryDropped:
+		logger.out.countAttached(droppedLabel)
+		return nil
+	case err != nil:
+		logger.out.countAttached(errorLabel)
+		if fallBack {
+			logger.writeFallback(encoded, err)
 		}
+		return err
+	}
+	if entriesTotal != nil {
+		entriesTotal.WithLabelValues(LevelName(level)).Inc()
 	}
+	logger.out.countAttached(LevelName(level))
 	return nil
 }
 
+// Implemented by writers that handle entries differently depending on their
+// level, such as syslog
+type levelWriter interface {
+	writeLevel(line string, level int) error
+}
+
+// Writes line, an entry at level, to every writer, returning the first error.
+// Must be called with lock held.
+func (out *output) write(line string, level int) error {
+	var firstErr error
+	for _, writer := range out.writers {
+		var err error
+		if leveled, ok := writer.(levelWriter); ok {
+			err = leveled.writeLevel(line, level)
+		} else {
+			_, err = io.WriteString(writer, line)
+		}
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Returns whether entries that fail to be written fall back to stdout, which
+// they don't if a writer is already a standard stream. Must be called with
+// lock held.
+func (out *output) fallsBack() bool {
+	for _, writer := range out.writers {
+		if isStandardStream(writer) || writer == fallbackWriter {
+			return false
+		}
+	}
+	return true
+}
+
+// Writes an entry that could not be written to stdout instead, followed by an
+// error entry reporting writeErr, so entries aren't lost when the
+// destination fails
+func (logger *Logger) writeFallback(encoded []byte, writeErr error) {
+	fallback := newLogger(logger.Name, fallbackWriter, nil)
+	fallback.encoding = logger.encoding
+	io.WriteString(fallbackWriter, string(encoded))
+	fallback.Error(fmt.Sprintf("Error writing to log: %s", writeErr.Error()))
+}
+
+// Returns whether writer is stdout or stderr, which are never closed
+func isStandardStream(writer io.Writer) bool {
+	return writer == io.Writer(os.Stdout) || writer == io.Writer(os.Stderr)
+}
+
 // Trace writes a log at TraceLevel
-func (logger *Logger) Trace(msg string, extras ...map[string]string) error {
-	if TraceLevel >= logger.LogLevel {
+func (logger *Logger) Trace(msg string, extras ...interface{}) error {
+	if TraceLevel >= logger.GetLogLevel() {
 		return logger.Log(msg, TraceLevel, extras...)
 	}
 	return nil
 }
 
 // Debug writes a log at DebugLevel
-func (logger *Logger) Debug(msg string, extras ...map[string]string) error {
-	if DebugLevel >= logger.LogLevel {
+func (logger *Logger) Debug(msg string, extras ...interface{}) error {
+	if DebugLevel >= logger.GetLogLevel() {
 		return logger.Log(msg, DebugLevel, extras...)
 	}
 	return nil
 }
 
 // Info writes a log at InfoLevel
-func (logger *Logger) Info(msg string, extras ...map[string]string) error {
-	if InfoLevel >= logger.LogLevel {
+func (logger *Logger) Info(msg string, extras ...interface{}) error {
+	if InfoLevel >= logger.GetLogLevel() {
 		return logger.Log(msg, InfoLevel, extras...)
 	}
 	return nil
 }
 
 // Warning writes a log at WarnLevel
-func (logger *Logger) Warning(msg string, extras ...map[string]string) error {
-	if WarnLevel >= logger.LogLevel {
+func (logger *Logger) Warning(msg string, extras ...interface{}) error {
+	if WarnLevel >= logger.GetLogLevel() {
 		return logger.Log(msg, WarnLevel, extras...)
 	}
 	return nil
 }
 
 // Error writes a log at ErrorLevel
-func (logger *Logger) Error(msg string, extras ...map[string]string) error {
-	if ErrorLevel >= logger.LogLevel {
+func (logger *Logger) Error(msg string, extras ...interface{}) error {
+	if ErrorLevel >= logger.GetLogLevel() {
 		return logger.Log(msg, ErrorLevel, extras...)
 	}
 	return nil
 }
 
 // Fatal writes a log at FatalLevel
-func (logger *Logger) Fatal(msg string, extras ...map[string]string) error {
-	if FatalLevel >= logger.LogLevel {
+func (logger *Logger) Fatal(msg string, extras ...interface{}) error {
+	if FatalLevel >= logger.GetLogLevel() {
 		return logger.Log(msg, FatalLevel, extras...)
 	}
 	return nil
 }
+
+// FatalAndExit writes a log at FatalLevel, flushes and closes the Logger's
+// destination and exits the process with status 1
+func (logger *Logger) FatalAndExit(msg string, extras ...interface{}) {
+	logger.Fatal(msg, extras...)
+	if logger.isChild {
+		logger.out.flush()
+	} else {
+		logger.Close()
+	}
+	logger.exitFunc(1)
+}
+
+// Flushes buffered writers, for children which can't close the destination
+func (out *output) flush() {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+	for _, writer := range out.writers {
+		if buffered, ok := writer.(flusher); ok {
+			buffered.Flush()
+		}
+	}
+}
+
+// Logf formats a message with fmt.Sprintf and writes it at level, if level is
+// enabled. The message isn't formatted otherwise.
+func (logger *Logger) Logf(level int, format string, args ...interface{}) error {
+	if level < logger.GetLogLevel() {
+		return nil
+	}
+	return logger.Log(fmt.Sprintf(format, args...), level)
+}
+
+// Tracef writes a formatted log at TraceLevel
+func (logger *Logger) Tracef(format string, args ...interface{}) error {
+	return logger.Logf(TraceLevel, format, args...)
+}
+
+// Debugf writes a formatted log at DebugLevel
+func (logger *Logger) Debugf(format string, args ...interface{}) error {
+	return logger.Logf(DebugLevel, format, args...)
+}
+
+// Infof writes a formatted log at InfoLevel
+func (logger *Logger) Infof(format string, args ...interface{}) error {
+	return logger.Logf(InfoLevel, format, args...)
+}
+
+// Warnf writes a formatted log at WarnLevel
+func (logger *Logger) Warnf(format string, args ...interface{}) error {
+	return logger.Logf(WarnLevel, format, args...)
+}
+
+// Errorf writes a formatted log at ErrorLevel
+func (logger *Logger) Errorf(format string, args ...interface{}) error {
+	return logger.Logf(ErrorLevel, format, args...)
+}
+
+// Fatalf writes a formatted log at FatalLevel
+func (logger *Logger) Fatalf(format string, args ...interface{}) error {
+	return logger.Logf(FatalLevel, format, args...)
+}
@@ -0,0 +1,885 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"          // Official Prometheus golang library
+	"github.com/prometheus/client_golang/prometheus/testutil" // Prometheus testing helpers
+	"github.com/stretchr/testify/assert"                      // Assertion package
+)
+
+// Returns a Logger writing to the returned buffer
+func newTestBufferLogger(name string) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return newLogger(name, &buf, nil), &buf
+}
+
+// Decodes every JSON line written to buf
+func decodeEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	scanner := bufio.NewScanner(bytes.NewReader(buf.Bytes()))
+	for scanner.Scan() {
+		entry := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Error unmarshalling log entry %q: %s", scanner.Text(), err.Error())
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Returns entries without the "Logger initialized" entries written by the
+// New*Logger constructors
+func withoutStartup(entries []map[string]interface{}) []map[string]interface{} {
+	var filtered []map[string]interface{}
+	for _, entry := range entries {
+		if entry["msg"] != "Logger initialized" {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+func TestSetTimeSource(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	fixed := time.Date(2016, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	logger.SetTimeSource(func() time.Time { return fixed })
+
+	assert.Nil(t, logger.Info("first"))
+	assert.Nil(t, logger.Info("second"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 2, len(entries))
+	for _, entry := range entries {
+		assert.Equal(t, "2016-01-02T03:04:05.600Z", entry["time"])
+	}
+}
+
+func TestSetTimeSourceBuffered(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger("test", bufio.NewWriterSize(&buf, 4096), nil)
+	logger.SetTimeSource(func() time.Time { return time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC) })
+
+	assert.Nil(t, logger.Info("buffered"))
+	assert.Equal(t, 0, buf.Len())
+	logger.Close()
+
+	entries := decodeEntries(t, &buf)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "2016-01-02T03:04:05.000Z", entries[0]["time"])
+}
+
+func TestGELFFormat(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetTimeSource(func() time.Time { return time.Unix(1234567890, 123000000) })
+	assert.Nil(t, logger.SetFormat(FormatGELF))
+	assert.NotNil(t, logger.SetFormat("xml"))
+
+	assert.Nil(t, logger.Error("gelf message", map[string]string{"request": "abc"}))
+	assert.Nil(t, logger.Debug("debug message"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "1.1", entries[0]["version"])
+	assert.Equal(t, logger.Hostname, entries[0]["host"])
+	assert.Equal(t, "gelf message", entries[0]["short_message"])
+	assert.Equal(t, 1234567890.123, entries[0]["timestamp"])
+	assert.Equal(t, float64(3), entries[0]["level"])
+	assert.Equal(t, "abc", entries[0]["_request"])
+	assert.Equal(t, "test", entries[0]["_name"])
+	assert.Nil(t, entries[0]["msg"])
+	assert.Equal(t, float64(7), entries[1]["level"])
+}
+
+func TestGELFFormatEntryID(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	assert.Nil(t, logger.SetFormat(FormatGELF))
+	logger.SetAddEntryID(true)
+
+	assert.Nil(t, logger.Info("with id"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 1, len(entries))
+	assert.Nil(t, entries[0]["_id"])
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, entries[0]["_entry_id"])
+}
+
+// Wraps Logger.Info the way a logging adapter would
+func adapterInfo(logger *Logger, msg string) error {
+	return logger.Info(msg)
+}
+
+func TestWithCallerSkip(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetIncludeCaller(true)
+
+	_, _, line, _ := runtime.Caller(0)
+	assert.Nil(t, logger.Info("direct"))
+	assert.Nil(t, adapterInfo(logger.WithCallerSkip(1), "through adapter"))
+	assert.Nil(t, adapterInfo(logger, "through adapter without skip"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 3, len(entries))
+	for i, entry := range entries {
+		src := entry["src"].(map[string]interface{})
+		assert.True(t, strings.HasSuffix(src["file"].(string), "logger_test.go"))
+		if i < 2 {
+			assert.Equal(t, float64(line+1+i), src["line"])
+			assert.True(t, strings.HasSuffix(src["func"].(string), ".TestWithCallerSkip"))
+		} else {
+			assert.True(t, strings.HasSuffix(src["func"].(string), ".adapterInfo"))
+		}
+	}
+
+	// Child loggers share the parent's destination but closing them is a no-op
+	flushErr, closeErr := logger.WithCallerSkip(1).Close()
+	assert.Nil(t, flushErr)
+	assert.Nil(t, closeErr)
+}
+
+func TestSetFieldOrder(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetTimeSource(func() time.Time { return time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC) })
+	logger.SetFieldOrder([]string{"time", "level", "msg", "missing"})
+
+	assert.Nil(t, logger.Info("ordered", map[string]string{"extra": "value"}))
+
+	expected := `{"time":"2016-01-02T03:04:05.000Z","level":30,"msg":"ordered","extra":"value","hostname":` +
+		strconv.Quote(logger.Hostname) + `,"name":"test","pid":` + strconv.Itoa(logger.Pid) + `,"v":0}` + "\n"
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestSetLogLevel(t *testing.T) {
+	logger, capture := NewTestLogger("test")
+	assert.Equal(t, TraceLevel, logger.GetLogLevel())
+
+	logger.SetLogLevel("warn")
+	assert.Equal(t, WarnLevel, logger.GetLogLevel())
+	assert.Nil(t, logger.Info("filtered"))
+	assert.Nil(t, logger.Warning("written", Fields{"attempt": 2}))
+
+	// Child loggers keep the level they were created with
+	child := logger.WithCallerSkip(0)
+	logger.SetLogLevel("error")
+	assert.Equal(t, WarnLevel, child.GetLogLevel())
+
+	entries := capture.Entries()
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "written", entries[0].Msg)
+	assert.Equal(t, "test", entries[0].Name)
+	assert.Equal(t, logger.Pid, entries[0].Pid)
+	assert.Equal(t, float64(2), entries[0].Fields["attempt"])
+	assert.Equal(t, 1, len(capture.EntriesAtLevel(WarnLevel)))
+	assert.Equal(t, 0, len(capture.EntriesAtLevel(InfoLevel)))
+	assert.True(t, capture.Contains("written"))
+	assert.False(t, capture.Contains("filtered"))
+
+	capture.Reset()
+	assert.Equal(t, 0, len(capture.Entries()))
+}
+
+func TestStartupLog(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "startup.log")
+	logger, err := NewLogger("startup", logPath)
+	assert.Nil(t, err)
+	logger.Close()
+
+	SetSuppressStartupLog(true)
+	defer SetSuppressStartupLog(false)
+	logger, err = NewLogger("suppressed", logPath)
+	assert.Nil(t, err)
+	logger.Close()
+
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	entries := decodeEntries(t, bytes.NewBuffer(raw))
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "Logger initialized", entries[0]["msg"])
+	assert.Equal(t, float64(InfoLevel), entries[0]["level"])
+	assert.Equal(t, "startup", entries[0]["logger_name"])
+	assert.Equal(t, float64(TraceLevel), entries[0]["log_level"])
+}
+
+func TestEnableMetrics(t *testing.T) {
+	logger, _ := newTestBufferLogger("test")
+	registry := prometheus.NewRegistry()
+	assert.Nil(t, logger.EnableMetrics("test", registry))
+
+	logger.Info("one")
+	logger.Info("two")
+	logger.Error("three")
+	assert.Equal(t, 2.0, testutil.ToFloat64(logger.out.entriesTotal.WithLabelValues("info")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(logger.out.entriesTotal.WithLabelValues("error")))
+
+	logger.DisableMetrics()
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(families))
+	assert.Nil(t, logger.Info("not counted"))
+}
+
+func TestTypedExtras(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+
+	assert.Nil(t, logger.Info("typed", Fields{
+		"count":   3,
+		"ratio":   0.5,
+		"enabled": true,
+		"missing": nil,
+		"nested":  map[string]interface{}{"ids": []int{1, 2}},
+	}, map[string]string{"legacy": "value"}))
+	assert.NotNil(t, logger.Info("invalid", "not a map"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, float64(3), entries[0]["count"])
+	assert.Equal(t, 0.5, entries[0]["ratio"])
+	assert.Equal(t, true, entries[0]["enabled"])
+	value, found := entries[0]["missing"]
+	assert.True(t, found)
+	assert.Nil(t, value)
+	assert.Equal(t, map[string]interface{}{"ids": []interface{}{float64(1), float64(2)}}, entries[0]["nested"])
+	assert.Equal(t, "value", entries[0]["legacy"])
+}
+
+// Writer that always fails and records whether it was closed
+type failingWriter struct {
+	closed bool
+}
+
+func (writer *failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func (writer *failingWriter) Close() error {
+	writer.closed = true
+	return nil
+}
+
+func TestMultiWriterLogger(t *testing.T) {
+	_, err := NewMultiWriterLogger("test")
+	assert.NotNil(t, err)
+
+	var first, second bytes.Buffer
+	logger, err := NewMultiWriterLogger("test", &first, &second)
+	assert.Nil(t, err)
+	assert.Nil(t, logger.Info("fan out"))
+	assert.Equal(t, first.String(), second.String())
+	assert.Equal(t, 1, len(withoutStartup(decodeEntries(t, &first))))
+
+	// A failing writer doesn't prevent the others from being written
+	failing := &failingWriter{}
+	var third bytes.Buffer
+	logger.AddWriter(failing)
+	logger.AddWriter(&third)
+	assert.NotNil(t, logger.Info("partial failure"))
+	assert.Equal(t, 2, len(withoutStartup(decodeEntries(t, &first))))
+	assert.Equal(t, 1, len(decodeEntries(t, &third)))
+
+	logger.Close()
+	assert.True(t, failing.closed)
+}
+
+func TestWriteFallback(t *testing.T) {
+	var stdout bytes.Buffer
+	fallbackWriter = &stdout
+	defer func() { fallbackWriter = os.Stdout }()
+
+	logger := newLogger("test", &failingWriter{}, nil)
+	assert.NotNil(t, logger.Info("not lost"))
+
+	entries := decodeEntries(t, &stdout)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "not lost", entries[0]["msg"])
+	assert.Equal(t, float64(ErrorLevel), entries[1]["level"])
+	assert.True(t, strings.HasPrefix(entries[1]["msg"].(string), "Error writing to log: "))
+
+	// Entries already written to stdout aren't written again
+	var other bytes.Buffer
+	logger, err := NewMultiWriterLogger("test", &failingWriter{}, fallbackWriter, &other)
+	assert.Nil(t, err)
+	stdout.Reset()
+	assert.NotNil(t, logger.Info("written once"))
+	assert.Equal(t, 1, len(withoutStartup(decodeEntries(t, &stdout))))
+}
+
+func TestChild(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	child := logger.Child(Fields{"request_id": "abc", "attempt": 1})
+	grandchild := child.Child(Fields{"attempt": 2})
+
+	logger.SetLogLevel("error")
+	assert.Nil(t, child.Info("child", Fields{"status": 200}))
+	assert.Nil(t, grandchild.Info("grandchild"))
+	assert.Nil(t, logger.Info("filtered"))
+
+	flushErr, closeErr := child.Close()
+	assert.Nil(t, flushErr)
+	assert.Nil(t, closeErr)
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "child", entries[0]["msg"])
+	assert.Equal(t, "test", entries[0]["name"])
+	assert.Equal(t, float64(InfoLevel), entries[0]["level"])
+	assert.Equal(t, "abc", entries[0]["request_id"])
+	assert.Equal(t, float64(1), entries[0]["attempt"])
+	assert.Equal(t, float64(200), entries[0]["status"])
+	assert.Equal(t, "abc", entries[1]["request_id"])
+	assert.Equal(t, float64(2), entries[1]["attempt"])
+}
+
+func TestSetSampleRate(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetSampleRate(TraceLevel, 10)
+	logger.SetSampleRate(DebugLevel, 5)
+
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, logger.Trace("trace"))
+	}
+	for i := 0; i < 100; i++ {
+		assert.Nil(t, logger.Debug("debug"))
+		assert.Nil(t, logger.Info("info"))
+	}
+
+	counts := make(map[string]int)
+	for _, entry := range decodeEntries(t, buf) {
+		counts[entry["msg"].(string)]++
+	}
+	assert.Equal(t, map[string]int{"trace": 100, "debug": 20, "info": 100}, counts)
+
+	// Without a rate of its own, trace uses the rate of the next level up
+	buf.Reset()
+	logger.SetSampleRate(TraceLevel, 1)
+	for i := 0; i < 10; i++ {
+		logger.Trace("trace")
+	}
+	assert.Equal(t, 2, len(decodeEntries(t, buf)))
+
+	// Levels sharing a rate are counted separately
+	buf.Reset()
+	for i := 0; i < 10; i++ {
+		logger.Trace("trace")
+		logger.Debug("debug")
+	}
+	counts = make(map[string]int)
+	for _, entry := range decodeEntries(t, buf) {
+		counts[entry["msg"].(string)]++
+	}
+	assert.Equal(t, map[string]int{"trace": 2, "debug": 2}, counts)
+}
+
+func TestEnvLogLevel(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "env.log")
+	t.Setenv("LOG_LEVEL", "error")
+	logger, err := NewLogger("env", logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, ErrorLevel, logger.GetLogLevel())
+	assert.Nil(t, logger.Info("filtered"))
+	logger.Close()
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(raw))
+
+	t.Setenv("SERVICE_LOG_LEVEL", "WARN")
+	logger, err = NewLoggerWithEnvLevel("env", "SERVICE_LOG_LEVEL", logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, WarnLevel, logger.GetLogLevel())
+	logger.Close()
+
+	t.Setenv("SERVICE_LOG_LEVEL", "verbose")
+	logger, err = NewLoggerWithEnvLevel("env", "SERVICE_LOG_LEVEL", logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, TraceLevel, logger.GetLogLevel())
+	logger.Close()
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	logger, _ := newTestBufferLogger("test")
+	handler := LogLevelHandler(logger)
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("PUT", "/loglevel", strings.NewReader(`{"level":"debug"}`)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, DebugLevel, logger.GetLogLevel())
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("GET", "/loglevel", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("PUT", "/loglevel", strings.NewReader(`{"level":"verbose"}`)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, DebugLevel, logger.GetLogLevel())
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, httptest.NewRequest("DELETE", "/loglevel", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+}
+
+func TestSetCallerSkip(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	assert.Nil(t, logger.Info("without caller"))
+
+	logger.SetIncludeCaller(true)
+	logger.SetCallerSkip(1)
+	assert.Nil(t, adapterInfo(logger, "through adapter"))
+
+	entries := decodeEntries(t, buf)
+	assert.Nil(t, entries[0]["src"])
+	src := entries[1]["src"].(map[string]interface{})
+	assert.True(t, strings.HasSuffix(src["file"].(string), "logger_test.go"))
+	assert.True(t, strings.HasSuffix(src["func"].(string), ".TestSetCallerSkip"))
+}
+
+func TestFatalAndExit(t *testing.T) {
+	var buf bytes.Buffer
+	logger := newLogger("test", bufio.NewWriterSize(&buf, 4096), nil)
+	exitCode := -1
+	logger.exitFunc = func(code int) {
+		// The entry is flushed before exiting
+		assert.Equal(t, 1, len(decodeEntries(t, &buf)))
+		exitCode = code
+	}
+
+	logger.Child(Fields{"request_id": "abc"}).FatalAndExit("fatal", Fields{"reason": "test"})
+	assert.Equal(t, 1, exitCode)
+
+	entries := decodeEntries(t, &buf)
+	assert.Equal(t, float64(FatalLevel), entries[0]["level"])
+	assert.Equal(t, "test", entries[0]["reason"])
+}
+
+func TestLogError(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	notFound := errors.New("not found")
+	err := fmt.Errorf("loading user: %w", notFound)
+
+	assert.Nil(t, logger.LogError(nil, "no error"))
+	assert.Nil(t, logger.ErrorWithErr(err, "request failed", Fields{"user": 1}))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "request failed", entries[0]["msg"])
+	assert.Equal(t, float64(ErrorLevel), entries[0]["level"])
+	assert.Equal(t, "loading user: not found", entries[0]["err"])
+	assert.Equal(t, "*fmt.wrapError", entries[0]["errType"])
+	assert.Equal(t, map[string]interface{}{"err": "not found", "errType": "*errors.errorString"}, entries[0]["cause"])
+	assert.Equal(t, float64(1), entries[0]["user"])
+}
+
+func TestSetCaptureStack(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetCaptureStack(true)
+
+	assert.Nil(t, logger.Error("with stack"))
+	assert.Nil(t, logger.Info("without stack"))
+	logger.SetCaptureStack(false, ErrorLevel)
+	assert.Nil(t, logger.Error("disabled"))
+
+	entries := decodeEntries(t, buf)
+	stack := entries[0]["stack"].([]interface{})
+	assert.True(t, strings.HasPrefix(stack[0].(string), "github.com/bottlenose-inc/go-common-tools/logger.TestSetCaptureStack ("))
+	assert.Contains(t, stack[0], "logger_test.go:")
+	assert.Nil(t, entries[1]["stack"])
+	assert.Nil(t, entries[2]["stack"])
+}
+
+func benchmarkCaptureStack(b *testing.B, enabled bool) {
+	logger := newLogger("bench", ioutil.Discard, nil)
+	logger.SetCaptureStack(enabled)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Error("benchmark")
+	}
+}
+
+func BenchmarkCaptureStackDisabled(b *testing.B) {
+	benchmarkCaptureStack(b, false)
+}
+
+func BenchmarkCaptureStackEnabled(b *testing.B) {
+	benchmarkCaptureStack(b, true)
+}
+
+// Context key type used by TestWithContext, as recommended by context.WithValue
+type testContextKey string
+
+func TestWithContext(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	ctx := context.WithValue(context.Background(), "request-id", "abc")
+	ctx = context.WithValue(ctx, testContextKey("tenant"), "acme")
+
+	assert.Nil(t, FromContext(ctx))
+	ctx = NewContext(ctx, logger.WithContext(ctx))
+	assert.Nil(t, FromContext(ctx).Info("default keys"))
+
+	logger.SetContextKeys(testContextKey("tenant"))
+	assert.Nil(t, logger.WithContext(ctx).Info("custom keys"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, "abc", entries[0]["request-id"])
+	assert.Nil(t, entries[0]["tenant"])
+	assert.Nil(t, entries[1]["request-id"])
+	assert.Equal(t, "acme", entries[1]["tenant"])
+}
+
+func TestFormatPretty(t *testing.T) {
+	line, err := formatPretty(map[string]interface{}{
+		"time":     "2016-01-02T03:04:05.000Z",
+		"level":    WarnLevel,
+		"name":     "test",
+		"msg":      "pretty message",
+		"hostname": "host",
+		"pid":      1,
+		"v":        0,
+		"user":     "abc",
+		"count":    3,
+		"tags":     []string{"a", "b"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, `[2016-01-02T03:04:05.000Z] WARN test: pretty message {count=3 tags=["a","b"] user=abc}`, string(line))
+
+	line, err = formatPretty(map[string]interface{}{"time": "2016-01-02T03:04:05.000Z", "level": TraceLevel, "name": "test", "msg": "bare"})
+	assert.Nil(t, err)
+	assert.Equal(t, "[2016-01-02T03:04:05.000Z] TRACE test: bare", string(line))
+}
+
+func TestSetPrettyPrint(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetTimeSource(func() time.Time { return time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC) })
+	logger.SetPrettyPrint(true)
+
+	assert.Nil(t, logger.Info("readable", Fields{"user": "abc"}))
+	assert.Equal(t, "[2016-01-02T03:04:05.000Z] INFO test: readable {user=abc}\n", buf.String())
+}
+
+func TestAddRedactedKeys(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.AddRedactedKeys("password")
+
+	assert.Nil(t, logger.Info("login", Fields{"user": "test", "password": "secret"}))
+	assert.Nil(t, logger.Info("password"))
+
+	assert.NotContains(t, buf.String(), "secret")
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, "[REDACTED]", entries[0]["password"])
+	assert.Equal(t, "test", entries[0]["user"])
+	assert.Equal(t, "login", entries[0]["msg"])
+	assert.Equal(t, "[REDACTED]", entries[1]["msg"])
+}
+
+func TestSetFieldNames(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetFieldNames(FieldNames{Msg: "message", Time: "@timestamp"})
+
+	assert.Nil(t, logger.Info("renamed"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, "renamed", entries[0]["message"])
+	assert.NotNil(t, entries[0]["@timestamp"])
+	assert.Nil(t, entries[0]["msg"])
+	assert.Nil(t, entries[0]["time"])
+	assert.Equal(t, float64(InfoLevel), entries[0]["level"])
+	assert.Equal(t, "test", entries[0]["name"])
+}
+
+// Records whether it was formatted
+type formatRecorder struct {
+	formatted bool
+}
+
+func (recorder *formatRecorder) String() string {
+	recorder.formatted = true
+	return "formatted"
+}
+
+func TestLogf(t *testing.T) {
+	logger, capture := NewTestLogger("test")
+	logger.SetLogLevel("info")
+
+	assert.Nil(t, logger.Infof("user %d: %s", 42, "created"))
+	assert.Nil(t, logger.Errorf("failed after %.1fs", 1.5))
+
+	recorder := &formatRecorder{}
+	assert.Nil(t, logger.Debugf("filtered %v", recorder))
+	assert.False(t, recorder.formatted)
+	assert.Nil(t, logger.Warnf("written %v", recorder))
+	assert.True(t, recorder.formatted)
+
+	entries := capture.Entries()
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, "user 42: created", entries[0].Msg)
+	assert.Equal(t, InfoLevel, entries[0].Level)
+	assert.Equal(t, "failed after 1.5s", entries[1].Msg)
+	assert.Equal(t, ErrorLevel, entries[1].Level)
+	assert.Equal(t, "written formatted", entries[2].Msg)
+}
+
+func TestStdLogger(t *testing.T) {
+	logger, capture := NewTestLogger("test")
+
+	fmt.Fprintln(logger, "written directly")
+	logger.StdLogger(ErrorLevel).Printf("from %s", "stdlib")
+	logger.SetLogLevel("fatal")
+	logger.StdLogger(ErrorLevel).Print("filtered")
+
+	entries := capture.Entries()
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "written directly", entries[0].Msg)
+	assert.Equal(t, InfoLevel, entries[0].Level)
+	assert.Equal(t, "from stdlib", entries[1].Msg)
+	assert.Equal(t, ErrorLevel, entries[1].Level)
+}
+
+func TestStdLoggerCaller(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetIncludeCaller(true)
+
+	_, _, line, _ := runtime.Caller(0)
+	logger.Write([]byte("written directly\n"))
+	logger.StdLogger(InfoLevel).Print("from stdlib")
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 2, len(entries))
+	for i, entry := range entries {
+		src := entry["src"].(map[string]interface{})
+		assert.True(t, strings.HasSuffix(src["file"].(string), "logger_test.go"))
+		assert.Equal(t, float64(line+1+i), src["line"])
+		assert.True(t, strings.HasSuffix(src["func"].(string), ".TestStdLoggerCaller"))
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var before, after bytes.Buffer
+	logger := newLogger("test", bufio.NewWriterSize(&before, 4096), nil)
+	child := logger.Child(nil)
+
+	assert.Nil(t, logger.Info("before"))
+	logger.SetOutput(&after)
+	assert.Nil(t, child.Info("after"))
+
+	beforeEntries := decodeEntries(t, &before)
+	afterEntries := decodeEntries(t, &after)
+	assert.Equal(t, 1, len(beforeEntries))
+	assert.Equal(t, "before", beforeEntries[0]["msg"])
+	assert.Equal(t, 1, len(afterEntries))
+	assert.Equal(t, "after", afterEntries[0]["msg"])
+
+	logPath := filepath.Join(t.TempDir(), "swapped.log")
+	file, err := os.Create(logPath)
+	assert.Nil(t, err)
+	logger.SetFile(file)
+	assert.Nil(t, logger.Info("to file"))
+	logger.Close()
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(decodeEntries(t, bytes.NewBuffer(raw))))
+	assert.NotNil(t, file.Close(), "file should already be closed")
+}
+
+func TestGoRecover(t *testing.T) {
+	logger, capture := NewTestLogger("test")
+
+	logger.GoRecover(func() {
+		panic("worker failed")
+	})
+	func() {
+		defer logger.RecoverAndLog()()
+		var values []int
+		_ = values[1]
+	}()
+	logger.GoRecover(func() {})
+
+	entries := capture.EntriesAtLevel(FatalLevel)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "worker failed", entries[0].Fields["panic"])
+	assert.Contains(t, entries[1].Fields["panic"], "index out of range")
+	stack := entries[0].Fields["stack"].([]interface{})
+	assert.True(t, len(stack) > 0)
+	assert.Contains(t, fmt.Sprint(stack), "TestGoRecover")
+}
+
+func TestSetMessageRateLimit(t *testing.T) {
+	logger, capture := NewTestLogger("test")
+	logger.SetMessageRateLimit(10)
+	defer logger.Close()
+
+	for i := 0; i < 1000; i++ {
+		assert.Nil(t, logger.Info("flood"))
+	}
+	assert.Nil(t, logger.Info("other"))
+
+	assert.Equal(t, 10, len(capture.EntriesAtLevel(InfoLevel))-1)
+	assert.True(t, capture.Contains("other"))
+}
+
+func TestMessageRateLimitSummary(t *testing.T) {
+	logger, capture := NewTestLogger("test")
+	logger.setMessageRateLimit(2, 20*time.Millisecond)
+	defer logger.Close()
+
+	for i := 0; i < 5; i++ {
+		logger.Info("flood")
+	}
+	assert.Eventually(t, func() bool { return capture.Contains("suppressed 3 identical messages") }, time.Second, 5*time.Millisecond)
+
+	// Counts are reset after each interval
+	summary := capture.EntriesAtLevel(WarnLevel)[0]
+	assert.Equal(t, "flood", summary.Fields["suppressed_msg"])
+	assert.Equal(t, float64(3), summary.Fields["suppressed_count"])
+	assert.Nil(t, logger.Info("flood"))
+	assert.Equal(t, 3, len(capture.EntriesAtLevel(InfoLevel)))
+}
+
+func TestAttachMetrics(t *testing.T) {
+	logger, _ := newTestBufferLogger("test")
+	counterVec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_log_entries_total", Help: "Log entries"}, []string{"level"})
+	logger.AttachMetrics(counterVec)
+
+	for i := 0; i < 10; i++ {
+		logger.Info("info")
+	}
+	for i := 0; i < 5; i++ {
+		logger.Debug("debug")
+	}
+	logger.SetSampleRate(TraceLevel, 2)
+	logger.Trace("sampled")
+	logger.Trace("dropped")
+	assert.NotNil(t, logger.Info("invalid", Fields{"value": func() {}}))
+
+	assert.Equal(t, 10.0, testutil.ToFloat64(counterVec.WithLabelValues("info")))
+	assert.Equal(t, 5.0, testutil.ToFloat64(counterVec.WithLabelValues("debug")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counterVec.WithLabelValues("trace")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counterVec.WithLabelValues("dropped")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counterVec.WithLabelValues("error")))
+}
+
+func TestStartAutoFlush(t *testing.T) {
+	capture := new(LogCapture)
+	logger := newLogger("test", bufio.NewWriterSize(capture, 4096), nil)
+	stop := logger.StartAutoFlush(10 * time.Millisecond)
+
+	assert.Nil(t, logger.Info("buffered"))
+	assert.Equal(t, 0, len(capture.Entries()))
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, capture.Contains("buffered"))
+
+	stop()
+	assert.Nil(t, logger.Info("not flushed"))
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, capture.Contains("not flushed"))
+
+	// Non-positive intervals stop flushing rather than panicking
+	for _, interval := range []time.Duration{0, -time.Second} {
+		logger.StartAutoFlush(10 * time.Millisecond)
+		stop = logger.StartAutoFlush(interval)
+		assert.Nil(t, logger.Info("disabled"))
+		time.Sleep(30 * time.Millisecond)
+		assert.False(t, capture.Contains("disabled"), interval)
+		stop()
+	}
+
+	// Close stops a running auto flush
+	logger.StartAutoFlush(time.Millisecond)
+	logger.Close()
+	assert.True(t, capture.Contains("not flushed"))
+}
+
+func TestLevelNames(t *testing.T) {
+	for _, level := range []int{TraceLevel, DebugLevel, InfoLevel, WarnLevel, ErrorLevel, FatalLevel} {
+		parsed, err := ParseLevel(LevelName(level))
+		assert.Nil(t, err)
+		assert.Equal(t, level, parsed)
+		assert.Equal(t, LevelName(level), LevelName(parsed))
+	}
+	_, err := ParseLevel("verbose")
+	assert.NotNil(t, err)
+	assert.Equal(t, "35", LevelName(35))
+
+	logger, _ := newTestBufferLogger("test")
+	logger.SetLogLevel("warn")
+	assert.False(t, logger.IsLevelEnabled(InfoLevel))
+	assert.True(t, logger.IsLevelEnabled(WarnLevel))
+	assert.True(t, logger.IsLevelEnabled(FatalLevel))
+}
+
+func TestCustomLevels(t *testing.T) {
+	assert.NotNil(t, RegisterCustomLevel("loud", InfoLevel))
+	assert.NotNil(t, RegisterCustomLevel("info", 36))
+	assert.Nil(t, RegisterCustomLevel("AUDIT", 35))
+	defer CustomLevels.remove(35)
+	assert.NotNil(t, RegisterCustomLevel("audit", 37))
+	assert.NotNil(t, RegisterCustomLevel("compliance", 35))
+	assert.Equal(t, "audit", CustomLevels.Levels()[35])
+
+	level, err := ParseLevel("audit")
+	assert.Nil(t, err)
+	assert.Equal(t, 35, level)
+	assert.Equal(t, "audit", LevelName(35))
+
+	logger, capture := NewTestLogger("test")
+	logger.SetLogLevel("warn")
+	assert.Nil(t, logger.LogAtLevel(35, "audited"))
+	assert.Nil(t, logger.LogAtLevel(InfoLevel, "filtered"))
+	logger.SetCustomLevelFiltered(35, true)
+	assert.Nil(t, logger.LogAtLevel(35, "filtered audit"))
+
+	entries := capture.Entries()
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "audited", entries[0].Msg)
+	assert.Equal(t, 35, entries[0].Level)
+}
+
+func TestSIGHUPReopen(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+	for _, buffered := range []bool{false, true} {
+		var logger *Logger
+		var err error
+		if buffered {
+			logger, err = NewBufferedLogger("test", 4096, logPath)
+		} else {
+			logger, err = NewLogger("test", logPath)
+		}
+		assert.Nil(t, err)
+		signals := make(chan os.Signal)
+		stop := logger.reopenOn(signals)
+
+		// Rename the file as logrotate would, then signal
+		assert.Nil(t, logger.Info("before rotation"))
+		assert.Nil(t, os.Rename(logPath, logPath+".1"))
+		signals <- syscall.SIGHUP
+		signals <- syscall.SIGHUP // waits until the first has been handled
+		assert.Nil(t, logger.Info("after rotation"))
+		stop()
+		logger.Close()
+
+		for path, msg := range map[string]string{logPath + ".1": "before rotation", logPath: "after rotation"} {
+			raw, err := ioutil.ReadFile(path)
+			assert.Nil(t, err)
+			entries := withoutStartup(decodeEntries(t, bytes.NewBuffer(raw)))
+			assert.Equal(t, 1, len(entries), path)
+			assert.Equal(t, msg, entries[0]["msg"])
+		}
+		os.Remove(logPath)
+	}
+}
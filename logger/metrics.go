@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// Labels counted by AttachMetrics for entries that weren't written
+const (
+	droppedLabel = "dropped"
+	errorLabel   = "error"
+)
+
+// Returned by writers that discard an entry on purpose, such as an
+// asynchronous writer with a full queue
+var errEntryDropped = errors.New("log entry dropped")
+
+// EnableMetrics registers a log_entries_total{level} counter with registry,
+// or the global registry if nil, and increments it for every entry written.
+// The counter is shared with child Loggers.
+func (logger *Logger) EnableMetrics(namespace string, registry *prometheus.Registry) error {
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if registry != nil {
+		registerer = registry
+	}
+
+	logger.DisableMetrics()
+	entriesTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name:      "log_entries_total",
+		Help:      "Number of log entries written, by level",
+		Namespace: namespace,
+	}, []string{"level"})
+	if err := registerer.Register(entriesTotal); err != nil {
+		return err
+	}
+
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	logger.out.entriesTotal = entriesTotal
+	logger.out.registerer = registerer
+	return nil
+}
+
+// DisableMetrics stops counting log entries and unregisters the counter
+func (logger *Logger) DisableMetrics() {
+	logger.out.lock.Lock()
+	defer logger.out.lock.Unlock()
+	if logger.out.entriesTotal != nil {
+		logger.out.registerer.Unregister(logger.out.entriesTotal)
+		logger.out.entriesTotal = nil
+		logger.out.registerer = nil
+	}
+}
+
+// AttachMetrics increments counterVec, which must have a single "level"
+// label, for every entry: with the level's name (see LevelName) when written,
+// "dropped" when skipped by sampling, rate limiting or a full asynchronous
+// queue, and "error" when writing fails. Unlike EnableMetrics, the counter is
+// registered by the caller. Pass nil to detach. Shared with child Loggers.
+func (logger *Logger) AttachMetrics(counterVec *prometheus.CounterVec) {
+	logger.out.attachedMetrics.Store(counterVec)
+}
+
+// Increments the attached counter, if any, for label
+func (out *output) countAttached(label string) {
+	if counterVec := out.attachedMetrics.Load(); counterVec != nil {
+		counterVec.WithLabelValues(label).Inc()
+	}
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5" // MessagePack encoding
+)
+
+// LogEncoding is the serialization of log entries, see SetEncoding
+type LogEncoding int
+
+const (
+	EncodingJSON    LogEncoding = iota // newline-delimited JSON, the default
+	EncodingMsgpack                    // length-prefixed MessagePack frames
+)
+
+// Largest msgpack frame accepted by DecodeMsgpackEntry
+const maxMsgpackFrame = 64 << 20
+
+// SetEncoding sets the serialization of log entries. With EncodingMsgpack
+// each entry is written as a MessagePack map prefixed with its length as a 4
+// byte big-endian integer, which DecodeMsgpackEntry reads back. Field order
+// and pretty printing only apply to JSON.
+func (logger *Logger) SetEncoding(encoding LogEncoding) error {
+	switch encoding {
+	case EncodingJSON, EncodingMsgpack:
+		logger.encoding = encoding
+		return nil
+	default:
+		return fmt.Errorf("Unsupported log encoding: %d", encoding)
+	}
+}
+
+// Returns the bytes written for a log entry in the configured encoding
+func (logger *Logger) encode(logEntry map[string]interface{}) ([]byte, error) {
+	if logger.encoding == EncodingMsgpack {
+		return marshalMsgpackFrame(logEntry)
+	}
+	logJson, err := logger.marshal(logEntry)
+	if err != nil {
+		return nil, err
+	}
+	return append(logJson, '\n'), nil
+}
+
+// Encodes logEntry as a msgpack map prefixed with its length. Structs are
+// encoded as maps keyed by their json tags, like the JSON encoding.
+func marshalMsgpackFrame(logEntry map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 4))
+	encoder := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(encoder)
+	encoder.Reset(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(logEntry); err != nil {
+		return nil, err
+	}
+	frame := buf.Bytes()
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-4))
+	return frame, nil
+}
+
+// Returns the line written in place of an entry that could not be encoded.
+// With EncodingMsgpack this is a framed error entry, so the stream stays
+// readable by DecodeMsgpackEntry.
+func (logger *Logger) encodeError(err error) string {
+	msg := fmt.Sprintf("Error marshalling log entry: %s", err.Error())
+	if logger.encoding != EncodingMsgpack {
+		return msg
+	}
+	frame, err := marshalMsgpackFrame(map[string]interface{}{
+		"hostname": logger.Hostname,
+		"level":    ErrorLevel,
+		"msg":      msg,
+		"name":     logger.Name,
+		"pid":      logger.Pid,
+		"time":     logger.now().Format(bunyanTimeFormat),
+		"v":        BunyanSyntaxVersion,
+	})
+	if err != nil {
+		return ""
+	}
+	return string(frame)
+}
+
+// DecodeMsgpackEntry reads one length-prefixed entry written with
+// EncodingMsgpack from r. Integers are decoded as int64 (uint64 if too large),
+// floats as float64, strings and binary as string, arrays as []interface{}
+// and maps as map[string]interface{}. Returns io.EOF if r has no more entries.
+func DecodeMsgpackEntry(r io.Reader) (map[string]interface{}, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(length[:])
+	if size > maxMsgpackFrame {
+		return nil, fmt.Errorf("msgpack entry of %d bytes exceeds the %d byte limit", size, maxMsgpackFrame)
+	}
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	reader := bytes.NewReader(frame)
+	decoder := msgpack.NewDecoder(reader)
+	decoder.UseLooseInterfaceDecoding(true)
+	value, err := decoder.DecodeInterfaceLoose()
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() > 0 {
+		return nil, errors.New("msgpack entry has trailing bytes")
+	}
+	logEntry, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("msgpack entry is a %T, not a map", value)
+	}
+	return logEntry, nil
+}
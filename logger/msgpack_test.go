@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestSetEncodingMsgpack(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	assert.Nil(t, logger.SetEncoding(EncodingMsgpack))
+	assert.NotNil(t, logger.SetEncoding(LogEncoding(42)))
+
+	type point struct {
+		X int `json:"x"`
+	}
+	longString := string(bytes.Repeat([]byte("a"), 300))
+	assert.Nil(t, logger.Info("first", Fields{
+		"small":    -5,
+		"negative": -40000,
+		"large":    int64(math.MaxInt64),
+		"unsigned": uint64(math.MaxUint64),
+		"float":    1.5,
+		"enabled":  true,
+		"missing":  nil,
+		"long":     longString,
+		"raw":      []byte{1, 2},
+		"tags":     []string{"a", "b"},
+		"nested":   map[string]interface{}{"list": []interface{}{1, "two"}},
+		"struct":   point{X: 3},
+	}))
+	assert.Nil(t, logger.Warning("second"))
+
+	first, err := DecodeMsgpackEntry(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "first", first["msg"])
+	assert.Equal(t, int64(InfoLevel), first["level"])
+	assert.Equal(t, "test", first["name"])
+	assert.Equal(t, int64(-5), first["small"])
+	assert.Equal(t, int64(-40000), first["negative"])
+	assert.Equal(t, int64(math.MaxInt64), first["large"])
+	assert.Equal(t, uint64(math.MaxUint64), first["unsigned"])
+	assert.Equal(t, 1.5, first["float"])
+	assert.Equal(t, true, first["enabled"])
+	assert.Nil(t, first["missing"])
+	assert.Equal(t, longString, first["long"])
+	assert.Equal(t, "\x01\x02", first["raw"])
+	assert.Equal(t, []interface{}{"a", "b"}, first["tags"])
+	assert.Equal(t, map[string]interface{}{"list": []interface{}{int64(1), "two"}}, first["nested"])
+	assert.Equal(t, map[string]interface{}{"x": int64(3)}, first["struct"])
+
+	second, err := DecodeMsgpackEntry(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "second", second["msg"])
+	assert.Equal(t, int64(WarnLevel), second["level"])
+
+	_, err = DecodeMsgpackEntry(buf)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeMsgpackEntryErrors(t *testing.T) {
+	frame, err := marshalMsgpackFrame(map[string]interface{}{"msg": "hello"})
+	assert.Nil(t, err)
+
+	_, err = DecodeMsgpackEntry(bytes.NewReader(frame[:len(frame)-1]))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+
+	// Length covering a truncated map
+	_, err = DecodeMsgpackEntry(bytes.NewReader([]byte{0, 0, 0, 2, 0x82, 0xa0}))
+	assert.NotNil(t, err)
+
+	// Not a map
+	_, err = DecodeMsgpackEntry(bytes.NewReader([]byte{0, 0, 0, 1, 0x01}))
+	assert.NotNil(t, err)
+}
+
+func TestMsgpackEncodeError(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.SetEncoding(EncodingMsgpack)
+
+	assert.NotNil(t, logger.Info("unencodable", Fields{"ch": make(chan int)}))
+	assert.Nil(t, logger.Info("after"))
+
+	failed, err := DecodeMsgpackEntry(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(ErrorLevel), failed["level"])
+	assert.Contains(t, failed["msg"], "Error marshalling log entry")
+	assert.Equal(t, "test", failed["name"])
+
+	after, err := DecodeMsgpackEntry(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "after", after["msg"])
+}
+
+func benchmarkEncoding(b *testing.B, encoding LogEncoding) {
+	logger := newLogger("bench", ioutil.Discard, nil)
+	logger.SetEncoding(encoding)
+	extras := map[string]interface{}{"request": "abc", "status": 200, "duration_ms": 12.5}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("benchmark", extras)
+	}
+}
+
+// Compare at 100k entries with -benchtime=100000x
+func BenchmarkEncodingJSON(b *testing.B) {
+	benchmarkEncoding(b, EncodingJSON)
+}
+
+func BenchmarkEncodingMsgpack(b *testing.B) {
+	benchmarkEncoding(b, EncodingMsgpack)
+}
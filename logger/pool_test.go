@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestEnableEntryPool(t *testing.T) {
+	logger, buf := newTestBufferLogger("test")
+	logger.EnableEntryPool()
+
+	assert.Nil(t, logger.Info("first", map[string]string{"request": "abc"}))
+	assert.Nil(t, logger.Info("second"))
+
+	entries := decodeEntries(t, buf)
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, "abc", entries[0]["request"])
+	assert.Equal(t, "second", entries[1]["msg"])
+	assert.Nil(t, entries[1]["request"], "pooled entries should be cleared")
+}
+
+func benchmarkLog(b *testing.B, pooled bool) {
+	logger := newLogger("bench", ioutil.Discard, nil)
+	if pooled {
+		logger.EnableEntryPool()
+	}
+	extras := map[string]string{"request": "abc"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		logger.Info("benchmark", extras)
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	benchmarkLog(b, false)
+}
+
+func BenchmarkLogEntryPool(b *testing.B) {
+	benchmarkLog(b, true)
+}
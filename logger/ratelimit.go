@@ -0,0 +1,124 @@
+package logger
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Number of distinct messages tracked by SetMessageRateLimit, the least
+// recently logged are forgotten beyond it
+const rateLimitCacheSize = 10000
+
+// SetMessageRateLimit writes at most maxPerMinute entries with the same
+// message per minute, dropping the rest. At the start of the next minute an
+// entry at WarnLevel reports how many were suppressed. A maxPerMinute of 0 or
+// less removes the limit. The limit is shared with children created
+// afterwards.
+func (logger *Logger) SetMessageRateLimit(maxPerMinute int) {
+	logger.setMessageRateLimit(maxPerMinute, time.Minute)
+}
+
+func (logger *Logger) setMessageRateLimit(max int, interval time.Duration) {
+	if logger.rateLimiter != nil {
+		logger.rateLimiter.stop()
+		logger.rateLimiter = nil
+	}
+	if max > 0 {
+		summaryLogger := logger.child()
+		logger.rateLimiter = newRateLimiter(summaryLogger, int64(max), interval)
+	}
+}
+
+// Counts entries per message in the current interval
+type rateLimiter struct {
+	logger   *Logger // logs suppression summaries, without a rate limit itself
+	max      int64
+	lock     sync.Mutex // protects messages and recent
+	messages map[string]*list.Element
+	recent   *list.List // *messageCount, most recently logged first
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+type messageCount struct {
+	msg   string
+	count int64
+}
+
+func newRateLimiter(logger *Logger, max int64, interval time.Duration) *rateLimiter {
+	limiter := &rateLimiter{
+		logger:   logger,
+		max:      max,
+		messages: make(map[string]*list.Element),
+		recent:   list.New(),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go limiter.run(interval)
+	return limiter
+}
+
+// Returns whether an entry with message msg may be written
+func (limiter *rateLimiter) allow(msg string) bool {
+	limiter.lock.Lock()
+	element, found := limiter.messages[msg]
+	if found {
+		limiter.recent.MoveToFront(element)
+	} else {
+		element = limiter.recent.PushFront(&messageCount{msg: msg})
+		limiter.messages[msg] = element
+		if limiter.recent.Len() > rateLimitCacheSize {
+			oldest := limiter.recent.Back()
+			limiter.recent.Remove(oldest)
+			delete(limiter.messages, oldest.Value.(*messageCount).msg)
+		}
+	}
+	counter := element.Value.(*messageCount)
+	limiter.lock.Unlock()
+
+	return atomic.AddInt64(&counter.count, 1) <= limiter.max
+}
+
+// Resets counts every interval, reporting suppressed entries
+func (limiter *rateLimiter) run(interval time.Duration) {
+	defer close(limiter.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			limiter.reset()
+		case <-limiter.done:
+			return
+		}
+	}
+}
+
+// Resets the count of every message, logging how many entries were dropped
+func (limiter *rateLimiter) reset() {
+	limiter.lock.Lock()
+	suppressed := make(map[string]int64)
+	for msg, element := range limiter.messages {
+		count := atomic.SwapInt64(&element.Value.(*messageCount).count, 0)
+		if count > limiter.max {
+			suppressed[msg] = count - limiter.max
+		}
+	}
+	limiter.lock.Unlock()
+
+	for msg, count := range suppressed {
+		limiter.logger.log(fmt.Sprintf("suppressed %d identical messages", count), WarnLevel, map[string]interface{}{
+			"suppressed_msg":   msg,
+			"suppressed_count": count,
+		})
+	}
+}
+
+// Stops resetting counts and waits for the background goroutine to exit
+func (limiter *rateLimiter) stop() {
+	close(limiter.done)
+	<-limiter.stopped
+}
@@ -0,0 +1,28 @@
+package logger
+
+import (
+	"fmt"
+)
+
+// GoRecover calls fn, logging any panic at FatalLevel instead of letting it
+// crash the process. Intended for goroutines, e.g. go logger.GoRecover(work).
+func (logger *Logger) GoRecover(fn func()) {
+	defer logger.RecoverAndLog()()
+	fn()
+}
+
+// RecoverAndLog returns a function that recovers a panic and logs it at
+// FatalLevel with the panic value in the "panic" field and the call stack in
+// "stack". Must be deferred as returned:
+//
+//	defer logger.RecoverAndLog()()
+func (logger *Logger) RecoverAndLog() func() {
+	return func() {
+		if value := recover(); value != nil {
+			logger.Log("Recovered from panic", FatalLevel, Fields{
+				"panic": fmt.Sprint(value),
+				"stack": stack(),
+			})
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package logger
+
+// Value written in place of redacted fields
+const redactedValue = "[REDACTED]"
+
+// AddRedactedKeys replaces the values of the named fields with "[REDACTED]"
+// in every entry. A message consisting of just one of the names is redacted
+// too. Fields of nested values are not inspected.
+func (logger *Logger) AddRedactedKeys(keys ...string) {
+	redactedKeys := make(map[string]bool, len(logger.redactedKeys)+len(keys))
+	for key := range logger.redactedKeys {
+		redactedKeys[key] = true
+	}
+	for _, key := range keys {
+		redactedKeys[key] = true
+	}
+	logger.redactedKeys = redactedKeys
+}
+
+// Replaces the values of redacted fields in logEntry
+func (logger *Logger) redact(logEntry map[string]interface{}) {
+	if msg, ok := logEntry["msg"].(string); ok && logger.redactedKeys[msg] {
+		logEntry["msg"] = redactedValue
+	}
+	for field := range logEntry {
+		if logger.redactedKeys[field] {
+			logEntry[field] = redactedValue
+		}
+	}
+}
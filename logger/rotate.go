@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Placeholder in NewDailyRotatingLogger's path template, replaced by the UTC
+// date in dailyDateFormat
+const (
+	datePlaceholder = "{date}"
+	dailyDateFormat = "2006-01-02"
+)
+
+// Returns a Logger writing to the file at path, which is renamed to path.1
+// once writing an entry would grow it beyond maxBytes. Existing backups are
+// shifted to path.2, path.3 and so on, keeping at most backupCount of them.
+// args are accepted for consistency with the other New*Logger functions and
+// are currently ignored.
+func NewRotatingLogger(name string, path string, maxBytes int64, backupCount int, args ...string) (*Logger, error) {
+	if strings.TrimSpace(path) == "" {
+		return nil, fmt.Errorf("Rotating logger requires a log file path")
+	}
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("Rotating logger requires a positive max bytes")
+	}
+	writer := &rotatingWriter{path: path, maxBytes: maxBytes, backupCount: backupCount}
+	if err := writer.open(); err != nil {
+		return nil, err
+	}
+	logger := newLogger(name, writer, nil)
+	logger.logStartup()
+	return logger, nil
+}
+
+// Writes to a file, rotating it by size. Calls are serialized by the Logger.
+type rotatingWriter struct {
+	path        string
+	maxBytes    int64
+	backupCount int
+	file        *os.File
+	size        int64
+}
+
+// Opens the file at path for appending
+func (writer *rotatingWriter) open() error {
+	file, err := parseArgs(writer.path)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	writer.file = file
+	writer.size = info.Size()
+	return nil
+}
+
+func (writer *rotatingWriter) Write(p []byte) (int, error) {
+	if writer.size > 0 && writer.size+int64(len(p)) > writer.maxBytes {
+		if err := writer.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := writer.file.Write(p)
+	writer.size += int64(n)
+	return n, err
+}
+
+// Shifts backups and opens a fresh file, closing the current file once the
+// new one is open so that a failed rotation leaves it in use
+func (writer *rotatingWriter) rotate() error {
+	if writer.backupCount > 0 {
+		for i := writer.backupCount - 1; i > 0; i-- {
+			backup := writer.path + "." + strconv.Itoa(i)
+			if _, err := os.Stat(backup); err == nil {
+				if err := os.Rename(backup, writer.path+"."+strconv.Itoa(i+1)); err != nil {
+					return err
+				}
+			}
+		}
+		if err := os.Rename(writer.path, writer.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(writer.path); err != nil {
+		return err
+	}
+	old := writer.file
+	if err := writer.open(); err != nil {
+		return err
+	}
+	return old.Close()
+}
+
+func (writer *rotatingWriter) Close() error {
+	return writer.file.Close()
+}
+
+// Returns a Logger writing to the file at pathTemplate with "{date}" replaced
+// by the current UTC date (YYYY-MM-DD), switching to a new file at midnight
+// UTC. Files of previous days are left in place. The date follows the Logger's
+// SetTimeSource. args are accepted for consistency with the other New*Logger
+// functions and are currently ignored.
+func NewDailyRotatingLogger(name string, pathTemplate string, args ...string) (*Logger, error) {
+	if !strings.Contains(pathTemplate, datePlaceholder) {
+		return nil, fmt.Errorf("Daily rotating logger path template must contain %s", datePlaceholder)
+	}
+	writer := &dailyWriter{pathTemplate: pathTemplate, now: time.Now}
+	if err := writer.open(writer.now().UTC().Format(dailyDateFormat)); err != nil {
+		return nil, err
+	}
+	logger := newLogger(name, writer, nil)
+	logger.logStartup()
+	return logger, nil
+}
+
+// Writes to a file named after the current date. Calls are serialized by the
+// Logger.
+type dailyWriter struct {
+	pathTemplate string
+	now          func() time.Time
+	date         string
+	file         *os.File
+}
+
+// Opens the file for date for appending
+func (writer *dailyWriter) open(date string) error {
+	file, err := parseArgs(strings.Replace(writer.pathTemplate, datePlaceholder, date, -1))
+	if err != nil {
+		return err
+	}
+	writer.file = file
+	writer.date = date
+	return nil
+}
+
+// Replaces the clock deciding the date, see Logger.SetTimeSource
+func (writer *dailyWriter) setTimeSource(now func() time.Time) {
+	writer.now = now
+}
+
+func (writer *dailyWriter) Write(p []byte) (int, error) {
+	if date := writer.now().UTC().Format(dailyDateFormat); date != writer.date {
+		old := writer.file
+		if err := writer.open(date); err != nil {
+			return 0, err
+		}
+		if err := old.Close(); err != nil {
+			return 0, err
+		}
+	}
+	return writer.file.Write(p)
+}
+
+func (writer *dailyWriter) Close() error {
+	return writer.file.Close()
+}
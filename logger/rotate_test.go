@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestRotatingLogger(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "app.log")
+
+	_, err := NewRotatingLogger("test", "", 1024, 2)
+	assert.NotNil(t, err)
+	_, err = NewRotatingLogger("test", logPath, 0, 2, []string{}...)
+	assert.NotNil(t, err)
+
+	logger, err := NewRotatingLogger("test", logPath, 1024, 2, []string{}...)
+	assert.Nil(t, err)
+	message := strings.Repeat("x", 300)
+	for i := 0; i < 12; i++ {
+		assert.Nil(t, logger.Info(message))
+	}
+	flushErr, closeErr := logger.Close()
+	assert.Nil(t, flushErr)
+	assert.Nil(t, closeErr)
+
+	files, _ := filepath.Glob(logPath + "*")
+	assert.Equal(t, []string{logPath, logPath + ".1", logPath + ".2"}, files)
+	for _, file := range files {
+		raw, err := ioutil.ReadFile(file)
+		assert.Nil(t, err)
+		assert.True(t, len(raw) <= 1024)
+		assert.True(t, len(decodeEntries(t, bytes.NewBuffer(raw))) > 0)
+	}
+}
+
+func TestRotatingLoggerFailedRotation(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	writer := &rotatingWriter{path: logPath, maxBytes: 10, backupCount: 1}
+	assert.Nil(t, writer.open())
+	_, err := writer.Write([]byte("first line\n"))
+	assert.Nil(t, err)
+
+	// The backup can't replace a non-empty directory, so the file is kept
+	assert.Nil(t, os.MkdirAll(filepath.Join(logPath+".1", "blocker"), 0755))
+	_, err = writer.Write([]byte("second\n"))
+	assert.NotNil(t, err)
+	_, err = writer.file.Write([]byte("kept\n"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, os.RemoveAll(logPath+".1"))
+	_, err = writer.Write([]byte("third\n"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	raw, _ := ioutil.ReadFile(logPath + ".1")
+	assert.Equal(t, "first line\nkept\n", string(raw))
+	raw, _ = ioutil.ReadFile(logPath)
+	assert.Equal(t, "third\n", string(raw))
+}
+
+func TestDailyRotatingLogger(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewDailyRotatingLogger("test", "", []string{}...)
+	assert.NotNil(t, err)
+	_, err = NewDailyRotatingLogger("test", filepath.Join(dir, "app.log"))
+	assert.NotNil(t, err)
+
+	logger, err := NewDailyRotatingLogger("test", filepath.Join(dir, "app-{date}.log"))
+	assert.Nil(t, err)
+	now := time.Date(2016, 1, 2, 23, 59, 59, 0, time.UTC)
+	logger.SetTimeSource(func() time.Time { return now })
+
+	assert.Nil(t, logger.Info("before midnight"))
+	now = now.Add(time.Second)
+	assert.Nil(t, logger.Info("after midnight"))
+	logger.Close()
+
+	for date, msg := range map[string]string{"2016-01-02": "before midnight", "2016-01-03": "after midnight"} {
+		raw, err := ioutil.ReadFile(filepath.Join(dir, "app-"+date+".log"))
+		assert.Nil(t, err)
+		entries := decodeEntries(t, bytes.NewBuffer(raw))
+		assert.Equal(t, 1, len(entries))
+		assert.Equal(t, msg, entries[0]["msg"])
+	}
+}
+
+func TestDailyRotatingLoggerFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewDailyRotatingLogger("test", filepath.Join(dir, "app-{date}.log"))
+	assert.Nil(t, err)
+	now := time.Date(2016, 1, 2, 23, 59, 59, 0, time.UTC)
+	logger.SetTimeSource(func() time.Time { return now })
+	assert.Nil(t, logger.Info("before midnight"))
+
+	// The next day's file can't be opened, so the current one stays in use
+	blocked := filepath.Join(dir, "app-2016-01-03.log")
+	assert.Nil(t, os.Mkdir(blocked, 0755))
+	now = now.Add(time.Second)
+	writer := logger.out.writers[0].(*dailyWriter)
+	_, err = writer.Write([]byte("{}\n"))
+	assert.NotNil(t, err)
+	assert.Equal(t, "2016-01-02", writer.date)
+
+	assert.Nil(t, os.Remove(blocked))
+	assert.Nil(t, logger.Info("after midnight"))
+	logger.Close()
+
+	raw, err := ioutil.ReadFile(blocked)
+	assert.Nil(t, err)
+	entries := decodeEntries(t, bytes.NewBuffer(raw))
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, "after midnight", entries[0]["msg"])
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// Sample rates configured by SetSampleRate, replaced rather than modified so
+// it can be read without locking
+type sampling struct {
+	levels   []int // configured levels in ascending order
+	rates    map[int]int64
+	counters sync.Map // *atomic.Int64 counting the entries at each level, created as entries are logged
+}
+
+// SetSampleRate writes only one in every n entries at or below level, starting
+// with the first. Entries use the rate of the lowest configured level at or
+// above their own, so rates can differ per level, e.g. 100 for TraceLevel and
+// 10 for DebugLevel, but each level is counted separately. Skipped entries
+// return a nil error. An n of 1 or less removes the rate for level.
+func (logger *Logger) SetSampleRate(level int, n int) {
+	current := logger.sampling.Load()
+	updated := &sampling{rates: make(map[int]int64)}
+	if current != nil {
+		for configured, rate := range current.rates {
+			updated.rates[configured] = rate
+		}
+		current.counters.Range(func(counted, counter interface{}) bool {
+			if counted != level {
+				updated.counters.Store(counted, counter)
+			}
+			return true
+		})
+	}
+	delete(updated.rates, level)
+	if n > 1 {
+		updated.rates[level] = int64(n)
+	}
+	for configured := range updated.rates {
+		updated.levels = append(updated.levels, configured)
+	}
+	sort.Ints(updated.levels)
+
+	if len(updated.levels) == 0 {
+		updated = nil
+	}
+	logger.sampling.Store(updated)
+}
+
+// Returns whether an entry at level should be written according to the
+// configured sample rates
+func (logger *Logger) sampled(level int) bool {
+	current := logger.sampling.Load()
+	if current == nil {
+		return true
+	}
+	for _, configured := range current.levels {
+		if level <= configured {
+			counter, found := current.counters.Load(level)
+			if !found {
+				counter, _ = current.counters.LoadOrStore(level, new(atomic.Int64))
+			}
+			count := counter.(*atomic.Int64).Add(1) - 1
+			return count%current.rates[configured] == 0
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// EnableSIGHUPReopen reopens the log file at its original path whenever the
+// process receives SIGHUP, as expected by logrotate after renaming the file.
+// Only applies to Loggers created with a log file path by NewLogger,
+// NewLoggerWithEnvLevel or NewBufferedLogger. The returned function stops
+// listening for the signal.
+func (logger *Logger) EnableSIGHUPReopen() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	stop := logger.reopenOn(signals)
+	return func() {
+		signal.Stop(signals)
+		stop()
+	}
+}
+
+// Reopens the log file for every value received from signals until the
+// returned function is called
+func (logger *Logger) reopenOn(signals <-chan os.Signal) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-signals:
+				if err := logger.out.reopen(); err != nil {
+					logger.Error("Error reopening log file: " + err.Error())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// Opens a new file at path, replacing the current file as the destination
+// and closing it
+func (out *output) reopen() error {
+	out.lock.Lock()
+	defer out.lock.Unlock()
+	if out.path == "" || out.file == nil {
+		return nil
+	}
+	file, err := parseArgs(out.path)
+	if err != nil {
+		return err
+	}
+	for i, writer := range out.writers {
+		if writer == io.Writer(out.file) {
+			out.writers[i] = file
+		} else if buffered, ok := writer.(*bufio.Writer); ok && i == 0 {
+			// Created by NewBufferedLogger around the file
+			buffered.Flush()
+			buffered.Reset(file)
+		}
+	}
+	old := out.file
+	out.file = file
+	return old.Close()
+}
@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"log"
+	"strings"
+)
+
+// Write logs p, without its trailing newline, at InfoLevel, so the Logger can
+// be used as an io.Writer by libraries writing plain text logs
+func (logger *Logger) Write(p []byte) (int, error) {
+	return (&stdWriter{logger: logger, level: InfoLevel}).Write(p)
+}
+
+// StdLogger returns a standard library *log.Logger writing each message to
+// the Logger at level, e.g. for http.Server.ErrorLog
+func (logger *Logger) StdLogger(level int) *log.Logger {
+	return log.New(&stdWriter{logger: logger, level: level}, "", 0)
+}
+
+// Adapts a Logger to io.Writer, logging each write as a message at level
+type stdWriter struct {
+	logger *Logger
+	level  int
+}
+
+func (writer *stdWriter) Write(p []byte) (int, error) {
+	if writer.level < writer.logger.GetLogLevel() {
+		return len(p), nil
+	}
+	if err := writer.logger.Log(strings.TrimSuffix(string(p), "\n"), writer.level); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
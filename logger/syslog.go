@@ -0,0 +1,43 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"log/syslog"
+)
+
+// Returns a Logger sending entries to the syslog server at addr over network
+// ("tcp" or "udp"), or to the local syslog socket if network is empty.
+// priority is the syslog facility, such as syslog.LOG_LOCAL0; each entry's
+// severity is derived from its level. Entries are tagged with name. args are
+// accepted for consistency with the other New*Logger functions and are
+// currently ignored.
+func NewSyslogLogger(name, network, addr string, priority int, args ...string) (*Logger, error) {
+	writer, err := syslog.Dial(network, addr, syslog.Priority(priority), name)
+	if err != nil {
+		return nil, err
+	}
+	logger := newLogger(name, &syslogWriter{writer}, nil)
+	logger.logStartup()
+	return logger, nil
+}
+
+// Writes entries to syslog with the severity matching their level
+type syslogWriter struct {
+	*syslog.Writer
+}
+
+func (writer *syslogWriter) writeLevel(line string, level int) error {
+	switch {
+	case level >= FatalLevel:
+		return writer.Crit(line)
+	case level >= ErrorLevel:
+		return writer.Err(line)
+	case level >= WarnLevel:
+		return writer.Warning(line)
+	case level >= InfoLevel:
+		return writer.Info(line)
+	default:
+		return writer.Debug(line)
+	}
+}
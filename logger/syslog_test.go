@@ -0,0 +1,46 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"bufio"
+	"log/syslog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestSyslogLogger(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	lines := make(chan string, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	logger, err := NewSyslogLogger("test", "tcp", listener.Addr().String(), int(syslog.LOG_LOCAL0))
+	assert.Nil(t, err)
+	assert.Nil(t, logger.Info("over syslog"))
+	assert.Nil(t, logger.Error("syslog error"))
+	logger.Close()
+
+	// LOG_LOCAL0 is facility 16, info and err are severities 6 and 3
+	<-lines // startup entry
+	info, errLine := <-lines, <-lines
+	assert.True(t, strings.HasPrefix(info, "<134>"), info)
+	assert.Contains(t, info, `"msg":"over syslog"`)
+	assert.True(t, strings.HasPrefix(errLine, "<131>"), errLine)
+	assert.Contains(t, errLine, `"msg":"syslog error"`)
+}
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	walEntrySuffix = ".entry"
+	walTempSuffix  = ".tmp"
+)
+
+// Returns a Logger that durably writes each entry to its own file in walPath
+// before returning, and copies written entries to the log file given in args
+// (stdout if omitted) every flushInterval. Entries left in walPath by a crash
+// are copied to the log file on creation, so entries may be duplicated but
+// are never lost.
+func NewCrashSafeLogger(name string, walPath string, flushInterval time.Duration, args ...string) (*Logger, error) {
+	if flushInterval <= 0 {
+		return nil, fmt.Errorf("Crash safe logger requires a positive flush interval")
+	}
+	file, err := parseArgs(args...)
+	if err != nil {
+		return nil, err
+	}
+	wal, err := newWALWriter(walPath, file, flushInterval)
+	if err != nil {
+		if file != os.Stdout {
+			file.Close()
+		}
+		return nil, err
+	}
+	logger := newLogger(name, wal, file)
+	logger.logStartup()
+	return logger, nil
+}
+
+// Writes each log entry to a write-ahead log directory, copying entries to
+// dest in the background
+type walWriter struct {
+	dir  string
+	dest *os.File
+	seq  uint64
+	lock sync.Mutex // serializes copying entries to dest
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newWALWriter(dir string, dest *os.File, flushInterval time.Duration) (*walWriter, error) {
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	wal := &walWriter{dir: dir, dest: dest, stop: make(chan struct{}), done: make(chan struct{})}
+
+	// Discard partially written entries, which were never acknowledged, and
+	// replay the rest
+	temps, err := filepath.Glob(filepath.Join(dir, "*"+walTempSuffix))
+	if err != nil {
+		return nil, err
+	}
+	for _, temp := range temps {
+		os.Remove(temp)
+	}
+	if err := wal.Flush(); err != nil {
+		return nil, err
+	}
+
+	go wal.run(flushInterval)
+	return wal, nil
+}
+
+// Write durably stores p as a new entry. Calls are serialized by the Logger.
+func (wal *walWriter) Write(p []byte) (int, error) {
+	wal.seq++
+	name := fmt.Sprintf("%d-%020d", time.Now().UnixNano(), wal.seq)
+	temp := filepath.Join(wal.dir, name+walTempSuffix)
+
+	file, err := os.OpenFile(temp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+	if err != nil {
+		return 0, err
+	}
+	n, err := file.Write(p)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temp, filepath.Join(wal.dir, name+walEntrySuffix))
+	}
+	if err != nil {
+		os.Remove(temp)
+		return 0, err
+	}
+	syncDir(wal.dir)
+	return n, nil
+}
+
+// Flush copies all written entries to dest, in the order they were written,
+// and removes them once dest has been synced
+func (wal *walWriter) Flush() error {
+	wal.lock.Lock()
+	defer wal.lock.Unlock()
+
+	infos, err := ioutil.ReadDir(wal.dir)
+	if err != nil {
+		return err
+	}
+	var entries []string
+	for _, info := range infos {
+		if strings.HasSuffix(info.Name(), walEntrySuffix) {
+			entries = append(entries, filepath.Join(wal.dir, info.Name()))
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.Strings(entries)
+
+	for _, entry := range entries {
+		raw, err := ioutil.ReadFile(entry)
+		if err != nil {
+			return err
+		}
+		if _, err := wal.dest.Write(raw); err != nil {
+			return err
+		}
+	}
+	if wal.dest != os.Stdout {
+		if err := wal.dest.Sync(); err != nil {
+			return err
+		}
+	}
+	for _, entry := range entries {
+		if err := os.Remove(entry); err != nil {
+			return err
+		}
+	}
+	syncDir(wal.dir)
+	return nil
+}
+
+// Close stops the background copying. Remaining entries are copied by Flush,
+// which the Logger calls before Close.
+func (wal *walWriter) Close() error {
+	close(wal.stop)
+	<-wal.done
+	return nil
+}
+
+func (wal *walWriter) run(flushInterval time.Duration) {
+	defer close(wal.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := wal.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error copying write-ahead log entries: %s\n", err.Error())
+			}
+		case <-wal.stop:
+			return
+		}
+	}
+}
+
+// Makes renames and removals in dir durable. Not supported on all platforms,
+// so errors are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
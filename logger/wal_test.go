@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestCrashSafeLogger(t *testing.T) {
+	dir := t.TempDir()
+	walPath := filepath.Join(dir, "wal")
+	logPath := filepath.Join(dir, "main.log")
+
+	// Entry left behind by a crash, plus an unacknowledged partial write
+	wal, err := newWALWriter(walPath, nil, time.Hour)
+	assert.Nil(t, err)
+	wal.Close()
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(walPath, "0-00000000000000000001"+walEntrySuffix), []byte(`{"msg":"replayed"}`+"\n"), 0666))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(walPath, "0-00000000000000000002"+walTempSuffix), []byte(`{"msg":"partial`), 0666))
+
+	logger, err := NewCrashSafeLogger("test", walPath, time.Hour, logPath)
+	assert.Nil(t, err)
+
+	// Entries are in the write-ahead log as soon as Log returns
+	assert.Nil(t, logger.Info("first"))
+	assert.Nil(t, logger.Info("second"))
+	pending, _ := filepath.Glob(filepath.Join(walPath, "*"+walEntrySuffix))
+	assert.Equal(t, 3, len(pending)) // and the startup entry
+
+	flushErr, closeErr := logger.Close()
+	assert.Nil(t, flushErr)
+	assert.Nil(t, closeErr)
+
+	remaining, _ := ioutil.ReadDir(walPath)
+	assert.Equal(t, 0, len(remaining))
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	entries := withoutStartup(decodeEntries(t, bytes.NewBuffer(raw)))
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, "replayed", entries[0]["msg"])
+	assert.Equal(t, "first", entries[1]["msg"])
+	assert.Equal(t, "second", entries[2]["msg"])
+}
+
+func TestCrashSafeLoggerFlushInterval(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "main.log")
+
+	logger, err := NewCrashSafeLogger("test", filepath.Join(dir, "wal"), 10*time.Millisecond, logPath)
+	assert.Nil(t, err)
+	defer logger.Close()
+	assert.Nil(t, logger.Info("flushed"))
+
+	assert.Eventually(t, func() bool {
+		raw, _ := ioutil.ReadFile(logPath)
+		return bytes.Contains(raw, []byte(`"msg":"flushed"`))
+	}, time.Second, 10*time.Millisecond)
+}
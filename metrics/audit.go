@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bottlenose-inc/go-common-tools/logger" // go-common-tools logger package
+	"github.com/prometheus/client_golang/prometheus"   // Official Prometheus golang library
+)
+
+// MetricDescription describes a registered metric, see ListRegistered
+type MetricDescription struct {
+	Name        string
+	Type        string
+	Help        string
+	ConstLabels map[string]string
+}
+
+var (
+	auditLock   sync.Mutex
+	auditLogger *logger.Logger
+)
+
+// EnableCreationAudit logs every metric registered by a Create* function to
+// log at DebugLevel. Pass nil to disable.
+func EnableCreationAudit(log *logger.Logger) {
+	auditLock.Lock()
+	defer auditLock.Unlock()
+	auditLogger = log
+}
+
+// Logs a metric registered by a Create* function if auditing is enabled
+func recordCreation(metricType string, name string, namespace string, subsystem string, labels map[string]string) {
+	fqName := prometheus.BuildFQName(namespace, subsystem, name)
+
+	auditLock.Lock()
+	log := auditLogger
+	auditLock.Unlock()
+
+	if log != nil {
+		log.Debug("Registered Prometheus metric", map[string]string{
+			"metric_name":  fqName,
+			"metric_type":  metricType,
+			"namespace":    namespace,
+			"subsystem":    subsystem,
+			"const_labels": fmt.Sprint(labels),
+		})
+	}
+}
+
+// ListRegistered describes every metric in registry, or the global registry
+// if nil. ConstLabels are only known for metrics created by the package-level
+// Create* functions; use MetricsRegistry.ListRegistered for metrics created
+// by a MetricsRegistry.
+func ListRegistered(registry *prometheus.Registry) []MetricDescription {
+	if registry == nil {
+		return defaultRegistry.ListRegistered()
+	}
+	return describeFamilies(registry, nil)
+}
+
+// ListRegistered describes every metric in metricsRegistry's registry, with
+// the ConstLabels of the metrics created by its Create* methods
+func (metricsRegistry *MetricsRegistry) ListRegistered() []MetricDescription {
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if metricsRegistry.Registry != nil {
+		gatherer = metricsRegistry.Registry
+	}
+
+	metricsRegistry.lock.Lock()
+	defer metricsRegistry.lock.Unlock()
+	return describeFamilies(gatherer, metricsRegistry.constLabels)
+}
+
+// Describes the metrics gathered by gatherer, with their const labels looked
+// up by fully-qualified name in constLabels
+func describeFamilies(gatherer prometheus.Gatherer, constLabels map[string]map[string]string) []MetricDescription {
+	families, _ := gatherer.Gather()
+	descriptions := make([]MetricDescription, 0, len(families))
+	for _, family := range families {
+		descriptions = append(descriptions, MetricDescription{
+			Name:        family.GetName(),
+			Type:        strings.ToLower(family.GetType().String()),
+			Help:        family.GetHelp(),
+			ConstLabels: constLabels[family.GetName()],
+		})
+	}
+	return descriptions
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+	dto "github.com/prometheus/client_model/go"      // Prometheus metric data model
+)
+
+// BoolGauge is a gauge exposing boolean state such as a feature flag or
+// health indicator as 1 (enabled) or 0 (disabled)
+type BoolGauge struct {
+	gauge prometheus.Gauge
+}
+
+func CreateBoolGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (*BoolGauge, error) {
+	// "name" and "help" are required by Prometheus to create a gauge
+	// all other fields are optional
+	// Returns a BoolGauge with its gauge registered
+
+	gauge, err := CreateGauge(name, namespace, subsystem, help, labels)
+	if err != nil {
+		return nil, err
+	}
+	return &BoolGauge{gauge: gauge}, nil
+}
+
+// Set sets the gauge to 1 if enabled, otherwise 0
+func (boolGauge *BoolGauge) Set(enabled bool) {
+	if enabled {
+		boolGauge.gauge.Set(1)
+	} else {
+		boolGauge.gauge.Set(0)
+	}
+}
+
+// Enable sets the gauge to 1
+func (boolGauge *BoolGauge) Enable() {
+	boolGauge.Set(true)
+}
+
+// Disable sets the gauge to 0
+func (boolGauge *BoolGauge) Disable() {
+	boolGauge.Set(false)
+}
+
+// IsEnabled returns whether the gauge's current value is non-zero
+func (boolGauge *BoolGauge) IsEnabled() bool {
+	var metric dto.Metric
+	if err := boolGauge.gauge.Write(&metric); err != nil {
+		return false
+	}
+	return metric.GetGauge().GetValue() != 0
+}
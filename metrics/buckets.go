@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// ExponentialBuckets returns count histogram buckets, the first with upper
+// bound start and each following multiplied by factor, for use with
+// CreateHistogram. Returns an error unless start is positive, factor greater
+// than 1 and count at least 1.
+func ExponentialBuckets(start float64, factor float64, count int) ([]float64, error) {
+	if start <= 0 {
+		return nil, fmt.Errorf("Exponential buckets require a positive start, got %g", start)
+	}
+	if factor <= 1 {
+		return nil, fmt.Errorf("Exponential buckets require a factor greater than 1, got %g", factor)
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("Exponential buckets require a count of at least 1, got %d", count)
+	}
+	return prometheus.ExponentialBuckets(start, factor, count), nil
+}
+
+// LinearBuckets returns count histogram buckets, the first with upper bound
+// start and each following width wider, for use with CreateHistogram. Returns
+// an error unless start and width are positive and count at least 1.
+func LinearBuckets(start float64, width float64, count int) ([]float64, error) {
+	if start <= 0 {
+		return nil, fmt.Errorf("Linear buckets require a positive start, got %g", start)
+	}
+	if width <= 0 {
+		return nil, fmt.Errorf("Linear buckets require a positive width, got %g", width)
+	}
+	if count < 1 {
+		return nil, fmt.Errorf("Linear buckets require a count of at least 1, got %d", count)
+	}
+	return prometheus.LinearBuckets(start, width, count), nil
+}
@@ -0,0 +1,30 @@
+//go:build !prod
+
+package metrics
+
+// ClearRegistry unregisters every metric created by the package-level
+// Create* functions from the global registry, so tests can create them again
+// from scratch. Not available in builds with the prod tag.
+func ClearRegistry() {
+	defaultRegistry.Clear()
+}
+
+// Clear unregisters every metric created by metricsRegistry's Create*
+// methods, forgetting their const labels, and the metrics cached by its
+// GetOrCreate* methods. Not
+// available in builds with the prod tag.
+func (metricsRegistry *MetricsRegistry) Clear() {
+	registerer := metricsRegistry.registerer()
+	metricsRegistry.lock.Lock()
+	for _, collector := range metricsRegistry.collectors {
+		registerer.Unregister(collector)
+	}
+	metricsRegistry.collectors = nil
+	metricsRegistry.types = make(map[string]string)
+	metricsRegistry.constLabels = make(map[string]map[string]string)
+	metricsRegistry.lock.Unlock()
+
+	metricsRegistry.cacheLock.Lock()
+	metricsRegistry.cache = make(map[string]cachedMetric)
+	metricsRegistry.cacheLock.Unlock()
+}
@@ -0,0 +1,38 @@
+//go:build !prod
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil" // Prometheus testing helpers
+	"github.com/stretchr/testify/assert"                      // Assertion package
+)
+
+func TestClearRegistry(t *testing.T) {
+	counter, err := CreateCounter("cleared_counter", "test", "", "Cleared counter", nil)
+	assert.Nil(t, err)
+	counter.Add(5)
+	cached, err := GetOrCreateGauge("cleared_gauge", "test", "", "Cleared gauge", nil)
+	assert.Nil(t, err)
+
+	ClearRegistry()
+	for _, description := range ListRegistered(nil) {
+		assert.NotEqual(t, "test_cleared_counter", description.Name)
+	}
+
+	recreated, err := CreateCounter("cleared_counter", "test", "", "Cleared counter", nil)
+	assert.Nil(t, err)
+	assert.False(t, counter == recreated)
+	assert.Equal(t, 0.0, testutil.ToFloat64(recreated))
+	gauge, err := GetOrCreateGauge("cleared_gauge", "test", "", "Cleared gauge", nil)
+	assert.Nil(t, err)
+	assert.False(t, cached == gauge)
+
+	isolated := NewMetricsRegistry()
+	_, err = isolated.CreateCounter("cleared_counter", "test", "", "Cleared counter", map[string]string{"env": "test"})
+	assert.Nil(t, err)
+	isolated.Clear()
+	assert.Equal(t, 0, len(isolated.ListRegistered()))
+	assert.Equal(t, 0, len(isolated.constLabels))
+}
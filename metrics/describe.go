@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+	dto "github.com/prometheus/client_model/go"      // Prometheus metric data model
+)
+
+// Lines returned by DescribeAll before the rest are summarized
+const defaultDescribeMaxLines = 1000
+
+// DescribeAll returns one line per metric in registry, in the format
+// "<type> <name> - <help> [<sample_value>]", for logging at startup or while
+// debugging. Vector metrics get a line per label combination, histograms and
+// summaries report their sample count and sum. The global registry is
+// described when registry is nil. At most 1000 lines are returned, see
+// DescribeAllLimit.
+func DescribeAll(registry *prometheus.Registry) string {
+	return DescribeAllLimit(registry, defaultDescribeMaxLines)
+}
+
+// DescribeAllLimit is like DescribeAll, but returns at most maxLines metric
+// lines followed by a "... N more" line if there are more. maxLines <= 0
+// means no limit.
+func DescribeAllLimit(registry *prometheus.Registry, maxLines int) string {
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		gatherer = registry
+	}
+
+	families, err := gatherer.Gather()
+	lines := make([]string, 0, len(families))
+	omitted := 0
+	for _, family := range families {
+		metricType := strings.ToLower(family.GetType().String())
+		for _, metric := range family.GetMetric() {
+			if maxLines > 0 && len(lines) >= maxLines {
+				omitted++
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s%s - %s [%s]", metricType, family.GetName(), formatLabels(metric.GetLabel()), family.GetHelp(), formatValue(metric)))
+		}
+	}
+	if omitted > 0 {
+		lines = append(lines, fmt.Sprintf("... %d more", omitted))
+	}
+	if err != nil {
+		lines = append(lines, "error gathering metrics: "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Formats label pairs in Prometheus' {name="value"} notation
+func formatLabels(labels []*dto.LabelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(labels))
+	for _, label := range labels {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Formats the current value of a metric
+func formatValue(metric *dto.Metric) string {
+	switch {
+	case metric.Counter != nil:
+		return fmt.Sprint(metric.GetCounter().GetValue())
+	case metric.Gauge != nil:
+		return fmt.Sprint(metric.GetGauge().GetValue())
+	case metric.Histogram != nil:
+		return fmt.Sprintf("count=%d sum=%v", metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum())
+	case metric.Summary != nil:
+		return fmt.Sprintf("count=%d sum=%v", metric.GetSummary().GetSampleCount(), metric.GetSummary().GetSampleSum())
+	default:
+		return fmt.Sprint(metric.GetUntyped().GetValue())
+	}
+}
@@ -0,0 +1,17 @@
+// Package metrics wraps the official Prometheus client library with helpers
+// for creating, registering and serving metrics.
+//
+// Thread safety: all functions and methods in this package are safe for
+// concurrent use unless documented otherwise. The Create* functions and
+// methods register metrics while holding the lock of their MetricsRegistry
+// (the package-level functions share the one for the global Prometheus
+// registry), so concurrent calls never race on registration; creating an
+// identical metric again returns the one already registered, while a
+// conflicting metric with the same name returns an error. The metrics they
+// return are Prometheus collectors, which are themselves safe for concurrent
+// use. StartPrometheusMetricsServer registers a handler on the
+// default ServeMux and must only be called once per process.
+//
+// Tests can avoid sharing the global registry by creating metrics with the
+// methods of a MetricsRegistry from NewMetricsRegistry.
+package metrics
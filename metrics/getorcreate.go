@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// Metric returned by a GetOrCreate* method, with the type it was created as
+type cachedMetric struct {
+	metricType string
+	collector  prometheus.Collector
+}
+
+// Returns the metric cached under the fully-qualified name, or creates and
+// caches it. Returns ErrAlreadyRegistered if the cached metric was created as
+// a different metricType.
+func getOrCreate[T prometheus.Collector](metricsRegistry *MetricsRegistry, metricType string, namespace string, subsystem string, name string, create func() (T, error)) (T, error) {
+	fqName := prometheus.BuildFQName(namespace, subsystem, name)
+	var none T
+
+	metricsRegistry.cacheLock.RLock()
+	cached, found := metricsRegistry.cache[fqName]
+	metricsRegistry.cacheLock.RUnlock()
+	if !found {
+		created, err := create()
+		if err != nil {
+			return none, err
+		}
+		metricsRegistry.cacheLock.Lock()
+		if cached, found = metricsRegistry.cache[fqName]; !found {
+			cached = cachedMetric{metricType: metricType, collector: created}
+			metricsRegistry.cache[fqName] = cached
+		}
+		metricsRegistry.cacheLock.Unlock()
+	}
+
+	if cached.metricType != metricType {
+		return none, ErrAlreadyRegistered
+	}
+	metric, ok := cached.collector.(T)
+	if !ok {
+		return none, ErrAlreadyRegistered
+	}
+	return metric, nil
+}
+
+// GetOrCreateCounter returns the counter with the given fully-qualified name
+// previously returned by GetOrCreateCounter, or creates one with
+// CreateCounter. Cheap enough to call on hot paths, but help and labels are
+// ignored once the counter exists.
+func GetOrCreateCounter(name string, namespace string, subsystem string, help string, labels map[string]string) (prometheus.Counter, error) {
+	return defaultRegistry.GetOrCreateCounter(name, namespace, subsystem, help, labels)
+}
+
+// GetOrCreateCounter is like the package-level GetOrCreateCounter, but
+// registers with metricsRegistry
+func (metricsRegistry *MetricsRegistry) GetOrCreateCounter(name string, namespace string, subsystem string, help string, labels map[string]string) (prometheus.Counter, error) {
+	return getOrCreate(metricsRegistry, "counter", namespace, subsystem, name, func() (prometheus.Counter, error) {
+		return metricsRegistry.CreateCounter(name, namespace, subsystem, help, labels)
+	})
+}
+
+// GetOrCreateCounterVector is the counter vector equivalent of
+// GetOrCreateCounter
+func GetOrCreateCounterVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.CounterVec, error) {
+	return defaultRegistry.GetOrCreateCounterVector(name, namespace, subsystem, help, labels, labelNames)
+}
+
+// GetOrCreateCounterVector is like the package-level
+// GetOrCreateCounterVector, but registers with metricsRegistry
+func (metricsRegistry *MetricsRegistry) GetOrCreateCounterVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.CounterVec, error) {
+	return getOrCreate(metricsRegistry, "counter_vector", namespace, subsystem, name, func() (*prometheus.CounterVec, error) {
+		return metricsRegistry.CreateCounterVector(name, namespace, subsystem, help, labels, labelNames)
+	})
+}
+
+// GetOrCreateGauge is the gauge equivalent of GetOrCreateCounter
+func GetOrCreateGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (prometheus.Gauge, error) {
+	return defaultRegistry.GetOrCreateGauge(name, namespace, subsystem, help, labels)
+}
+
+// GetOrCreateGauge is like the package-level GetOrCreateGauge, but registers
+// with metricsRegistry
+func (metricsRegistry *MetricsRegistry) GetOrCreateGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (prometheus.Gauge, error) {
+	return getOrCreate(metricsRegistry, "gauge", namespace, subsystem, name, func() (prometheus.Gauge, error) {
+		return metricsRegistry.CreateGauge(name, namespace, subsystem, help, labels)
+	})
+}
+
+// GetOrCreateGaugeVector is the gauge vector equivalent of
+// GetOrCreateCounter
+func GetOrCreateGaugeVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.GaugeVec, error) {
+	return defaultRegistry.GetOrCreateGaugeVector(name, namespace, subsystem, help, labels, labelNames)
+}
+
+// GetOrCreateGaugeVector is like the package-level GetOrCreateGaugeVector,
+// but registers with metricsRegistry
+func (metricsRegistry *MetricsRegistry) GetOrCreateGaugeVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.GaugeVec, error) {
+	return getOrCreate(metricsRegistry, "gauge_vector", namespace, subsystem, name, func() (*prometheus.GaugeVec, error) {
+		return metricsRegistry.CreateGaugeVector(name, namespace, subsystem, help, labels, labelNames)
+	})
+}
+
+// GetOrCreateHistogram is the histogram equivalent of GetOrCreateCounter.
+// Buckets are ignored once the histogram exists.
+func GetOrCreateHistogram(name string, namespace string, subsystem string, help string, labels map[string]string, buckets ...[]float64) (prometheus.Histogram, error) {
+	return defaultRegistry.GetOrCreateHistogram(name, namespace, subsystem, help, labels, buckets...)
+}
+
+// GetOrCreateHistogram is like the package-level GetOrCreateHistogram, but
+// registers with metricsRegistry
+func (metricsRegistry *MetricsRegistry) GetOrCreateHistogram(name string, namespace string, subsystem string, help string, labels map[string]string, buckets ...[]float64) (prometheus.Histogram, error) {
+	return getOrCreate(metricsRegistry, "histogram", namespace, subsystem, name, func() (prometheus.Histogram, error) {
+		return metricsRegistry.CreateHistogram(name, namespace, subsystem, help, labels, buckets...)
+	})
+}
+
+// GetOrCreateHistogramVector is the histogram vector equivalent of
+// GetOrCreateCounter. Buckets are ignored once the histogram vector exists.
+func GetOrCreateHistogramVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, buckets ...[]float64) (*prometheus.HistogramVec, error) {
+	return defaultRegistry.GetOrCreateHistogramVector(name, namespace, subsystem, help, labels, labelNames, buckets...)
+}
+
+// GetOrCreateHistogramVector is like the package-level
+// GetOrCreateHistogramVector, but registers with metricsRegistry
+func (metricsRegistry *MetricsRegistry) GetOrCreateHistogramVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, buckets ...[]float64) (*prometheus.HistogramVec, error) {
+	return getOrCreate(metricsRegistry, "histogram_vector", namespace, subsystem, name, func() (*prometheus.HistogramVec, error) {
+		return metricsRegistry.CreateHistogramVector(name, namespace, subsystem, help, labels, labelNames, buckets...)
+	})
+}
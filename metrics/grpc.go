@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+	"google.golang.org/grpc"                         // gRPC
+	"google.golang.org/grpc/status"                  // gRPC status codes
+)
+
+// Values of the grpc_type label
+const (
+	grpcUnary        = "unary"
+	grpcClientStream = "client_stream"
+	grpcServerStream = "server_stream"
+	grpcBidiStream   = "bidi_stream"
+)
+
+// GRPCServerMetrics counts and times the requests handled by a gRPC server
+// with go-grpc-prometheus' metric names, labels and buckets
+type GRPCServerMetrics struct {
+	requests *prometheus.CounterVec
+	handling *prometheus.HistogramVec
+}
+
+// NewGRPCServerMetrics registers
+// grpc_server_handled_total{grpc_type,grpc_service,grpc_method,grpc_code} and
+// grpc_server_handling_seconds{grpc_type,grpc_service,grpc_method} with
+// registry, or the global registry if nil
+func NewGRPCServerMetrics(namespace string, registry *prometheus.Registry) (*GRPCServerMetrics, error) {
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if registry != nil {
+		registerer = registry
+	}
+
+	grpcMetrics := &GRPCServerMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:      "grpc_server_handled_total",
+			Help:      "Total number of RPCs completed on the server, regardless of success or failure.",
+			Namespace: namespace,
+		}, []string{"grpc_type", "grpc_service", "grpc_method", "grpc_code"}),
+		handling: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:      "grpc_server_handling_seconds",
+			Help:      "Histogram of response latency (seconds) of gRPC that had been application-level handled by the server.",
+			Namespace: namespace,
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"grpc_type", "grpc_service", "grpc_method"}),
+	}
+	if err := registerer.Register(grpcMetrics.requests); err != nil {
+		return nil, err
+	}
+	if err := registerer.Register(grpcMetrics.handling); err != nil {
+		registerer.Unregister(grpcMetrics.requests)
+		return nil, err
+	}
+	return grpcMetrics, nil
+}
+
+// UnaryServerInterceptor records metrics for unary RPCs
+func (grpcMetrics *GRPCServerMetrics) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		grpcMetrics.observe(grpcUnary, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records metrics for streaming RPCs
+func (grpcMetrics *GRPCServerMetrics) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		grpcMetrics.observe(streamType(info), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func (grpcMetrics *GRPCServerMetrics) observe(rpcType, fullMethod string, start time.Time, err error) {
+	service, method := splitMethodName(fullMethod)
+	grpcMetrics.handling.WithLabelValues(rpcType, service, method).Observe(time.Since(start).Seconds())
+	grpcMetrics.requests.WithLabelValues(rpcType, service, method, status.Code(err).String()).Inc()
+}
+
+// Returns the grpc_type of a streaming RPC
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return grpcBidiStream
+	case info.IsClientStream:
+		return grpcClientStream
+	case info.IsServerStream:
+		return grpcServerStream
+	}
+	return grpcUnary
+}
+
+// Splits a full method name such as "/package.Service/Method" into the service
+// and method names, or returns "unknown" for both if it is malformed
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.Index(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", "unknown"
+}
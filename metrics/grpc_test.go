@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"          // Official Prometheus golang library
+	"github.com/prometheus/client_golang/prometheus/testutil" // Prometheus testing helpers
+	"github.com/stretchr/testify/assert"                      // Assertion package
+	"google.golang.org/grpc"                                  // gRPC
+	"google.golang.org/grpc/codes"                            // gRPC status codes
+	"google.golang.org/grpc/status"                           // gRPC status codes
+)
+
+func TestGRPCServerMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	grpcMetrics, err := NewGRPCServerMetrics("test", registry)
+	assert.Nil(t, err)
+
+	unary := grpcMetrics.UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+	resp, err := unary(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "resp", nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "resp", resp)
+	_, err = unary(context.Background(), "req", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "missing")
+	})
+	assert.NotNil(t, err)
+
+	stream := grpcMetrics.StreamServerInterceptor()
+	err = stream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch", IsServerStream: true}, func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	})
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(grpcMetrics.requests.WithLabelValues("unary", "test.Service", "Get", "OK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(grpcMetrics.requests.WithLabelValues("unary", "test.Service", "Get", "NotFound")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(grpcMetrics.requests.WithLabelValues("server_stream", "test.Service", "Watch", "OK")))
+	count, _ := histogramValues(t, grpcMetrics.handling.WithLabelValues("unary", "test.Service", "Get"))
+	assert.Equal(t, uint64(2), count)
+}
+
+func TestSplitMethodName(t *testing.T) {
+	service, method := splitMethodName("/test.Service/Get")
+	assert.Equal(t, "test.Service", service)
+	assert.Equal(t, "Get", method)
+	service, method = splitMethodName("malformed")
+	assert.Equal(t, "unknown", service)
+	assert.Equal(t, "unknown", method)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/bottlenose-inc/go-common-tools/logger" // go-common-tools logger package
+)
+
+// StartMetricsServerWithHealth starts a MetricsServer on port with ready as
+// its readiness check, see SetReadinessCheck
+func StartMetricsServerWithHealth(logger *logger.Logger, port int, ready func() bool) (*MetricsServer, error) {
+	server := NewMetricsServer(logger, port, "")
+	server.SetReadinessCheck(ready)
+	if err := server.Start(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// SetReadinessCheck makes /readyz respond 503 {"status":"not ready"} while
+// ready returns false, rather than 200 {"status":"ok"}. A nil ready always
+// reports the server ready.
+func (server *MetricsServer) SetReadinessCheck(ready func() bool) {
+	server.readyLock.Lock()
+	defer server.readyLock.Unlock()
+	server.ready = ready
+}
+
+// Registers the /healthz liveness and /readyz readiness endpoints
+func (server *MetricsServer) handleHealth() {
+	server.Mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, http.StatusOK, "ok")
+	})
+	server.Mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		server.readyLock.RLock()
+		ready := server.ready
+		server.readyLock.RUnlock()
+		if ready == nil || ready() {
+			writeStatus(w, http.StatusOK, "ok")
+		} else {
+			writeStatus(w, http.StatusServiceUnavailable, "not ready")
+		}
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write([]byte(`{"status":"` + status + `"}`))
+}
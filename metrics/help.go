@@ -0,0 +1,133 @@
+package metrics
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// HelpServer serves a description of every metric on a MetricsServer's
+// /metrics-help path, see NewHelpServer
+type HelpServer struct {
+	Gatherer prometheus.Gatherer // defaults to the global registry
+}
+
+// Description of a metric served by HelpServer
+type metricHelp struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Help   string   `json:"help"`
+	Labels []string `json:"labels"`
+}
+
+var helpTemplate = template.Must(template.New("help").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>Metrics help</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { cursor: pointer; background: #eee; }
+</style>
+</head>
+<body>
+<h1>Metrics help</h1>
+<p><input id="filter" type="search" placeholder="Filter" size="40"></p>
+<table id="metrics">
+<thead><tr><th>Name</th><th>Type</th><th>Help</th><th>Labels</th></tr></thead>
+<tbody>
+{{range .}}<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{.Help}}</td><td>{{range $i, $label := .Labels}}{{if $i}}, {{end}}{{$label}}{{end}}</td></tr>
+{{end}}</tbody>
+</table>
+<script>
+var table = document.getElementById("metrics");
+var body = table.tBodies[0];
+document.getElementById("filter").addEventListener("input", function() {
+	var filter = this.value.toLowerCase();
+	Array.prototype.forEach.call(body.rows, function(row) {
+		row.style.display = row.textContent.toLowerCase().indexOf(filter) >= 0 ? "" : "none";
+	});
+});
+Array.prototype.forEach.call(table.tHead.rows[0].cells, function(header, column) {
+	var ascending = true;
+	header.addEventListener("click", function() {
+		var rows = Array.prototype.slice.call(body.rows);
+		rows.sort(function(a, b) {
+			var order = a.cells[column].textContent.localeCompare(b.cells[column].textContent);
+			return ascending ? order : -order;
+		});
+		ascending = !ascending;
+		rows.forEach(function(row) { body.appendChild(row); });
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+// NewHelpServer adds a GET /metrics-help handler to server describing the
+// name, type, help text and label names of each metric in the global
+// registry. The description is served as HTML, sortable and filterable in
+// the browser, or as JSON when requested with ?format=json or an Accept
+// header of application/json.
+func NewHelpServer(server *MetricsServer) *HelpServer {
+	help := &HelpServer{Gatherer: prometheus.DefaultGatherer}
+	server.Mux.Handle("/metrics-help", help)
+	return help
+}
+
+func (help *HelpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	descriptions, err := help.describe()
+	if err != nil {
+		http.Error(w, "Error gathering metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(descriptions)
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		helpTemplate.Execute(w, descriptions)
+	}
+}
+
+// Gathers the description of every metric, sorted by name
+func (help *HelpServer) describe() ([]metricHelp, error) {
+	families, err := help.Gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	descriptions := make([]metricHelp, 0, len(families))
+	for _, family := range families {
+		seen := make(map[string]bool)
+		labels := []string{}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if !seen[label.GetName()] {
+					seen[label.GetName()] = true
+					labels = append(labels, label.GetName())
+				}
+			}
+		}
+		sort.Strings(labels)
+		descriptions = append(descriptions, metricHelp{
+			Name:   family.GetName(),
+			Type:   strings.ToLower(family.GetType().String()),
+			Help:   family.GetHelp(),
+			Labels: labels,
+		})
+	}
+	sort.Slice(descriptions, func(i, j int) bool { return descriptions[i].Name < descriptions[j].Name })
+	return descriptions, nil
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// HighWaterGauge is a gauge that also tracks the highest value it has been
+// set to in a "<name>_high_water_mark" gauge. Both are backed by gauge
+// vectors labelled with the keys of labels, so gauges with the same name and
+// different label values can be created side by side.
+type HighWaterGauge struct {
+	gauge          prometheus.Gauge
+	highWaterGauge prometheus.Gauge
+	lock           sync.Mutex
+	current        float64
+	highWaterMark  float64
+	marked         bool // set by the first Set
+}
+
+func NewHighWaterMarkGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (*HighWaterGauge, error) {
+	// "name" and "help" are required by Prometheus to create a gauge
+	// all other fields are optional
+	// Returns a HighWaterGauge with both gauge vectors registered
+
+	labelNames := make([]string, 0, len(labels))
+	for labelName := range labels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+
+	gaugeVec, created, err := defaultRegistry.createGaugeVector(name, namespace, subsystem, help, nil, labelNames)
+	if err != nil {
+		return nil, err
+	}
+	highWaterVec, _, err := defaultRegistry.createGaugeVector(name+"_high_water_mark", namespace, subsystem, help+" (high water mark)", nil, labelNames)
+	if err != nil {
+		// Only unregister the gauge vector if it didn't exist before
+		if created {
+			defaultRegistry.unregister(gaugeVec, prometheus.BuildFQName(namespace, subsystem, name))
+		}
+		return nil, err
+	}
+	return &HighWaterGauge{gauge: gaugeVec.With(labels), highWaterGauge: highWaterVec.With(labels)}, nil
+}
+
+// Set sets the gauge to value, raising the high water mark if value exceeds
+// it. The first value set becomes the initial high water mark.
+func (hwg *HighWaterGauge) Set(value float64) {
+	hwg.lock.Lock()
+	defer hwg.lock.Unlock()
+	hwg.current = value
+	hwg.gauge.Set(value)
+	if !hwg.marked || value > hwg.highWaterMark {
+		hwg.marked = true
+		hwg.highWaterMark = value
+		hwg.highWaterGauge.Set(value)
+	}
+}
+
+// ResetHighWaterMark lowers the high water mark to the gauge's current value
+func (hwg *HighWaterGauge) ResetHighWaterMark() {
+	hwg.lock.Lock()
+	defer hwg.lock.Unlock()
+	hwg.highWaterMark = hwg.current
+	hwg.highWaterGauge.Set(hwg.current)
+}
@@ -0,0 +1,139 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// IntervalCounter is a CounterVec that resets to zero every interval. The
+// total of the previous interval is kept in a "<name>_interval_total" gauge
+// vector so it can be queried at any time. Label values seen once keep
+// reporting 0 in later intervals without events.
+type IntervalCounter struct {
+	counterVec    *prometheus.CounterVec
+	intervalTotal *prometheus.GaugeVec
+	registerer    prometheus.Registerer
+	lock          sync.Mutex
+	counts        map[string]*intervalCount // keyed by joined label values, kept across intervals
+	stop          chan struct{}
+	stopOnce      sync.Once
+}
+
+func NewIntervalCounter(name string, namespace string, subsystem string, help string, labelNames []string, resetInterval time.Duration, registry *prometheus.Registry) (*IntervalCounter, error) {
+	// "name" and "help" are required by Prometheus to create a counter vector
+	// registry is optional, the global registry is used if nil
+	// Returns an IntervalCounter that resets every resetInterval until stopped
+
+	if name == "" || help == "" {
+		return nil, errors.New("Prometheus interval counter requires both name and help fields to initialize - missing one or both of those fields")
+	}
+	if resetInterval <= 0 {
+		return nil, errors.New("Prometheus interval counter requires a positive reset interval")
+	}
+
+	var registerer prometheus.Registerer = prometheus.DefaultRegisterer
+	if registry != nil {
+		registerer = registry
+	}
+
+	intervalCounter := &IntervalCounter{
+		counterVec: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:      name,
+			Help:      help,
+			Namespace: namespace,
+			Subsystem: subsystem,
+		}, labelNames),
+		intervalTotal: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name:      name + "_interval_total",
+			Help:      help + " (total of the previous interval)",
+			Namespace: namespace,
+			Subsystem: subsystem,
+		}, labelNames),
+		registerer: registerer,
+		counts:     make(map[string]*intervalCount),
+		stop:       make(chan struct{}),
+	}
+	if err := registerer.Register(intervalCounter.counterVec); err != nil {
+		return nil, err
+	}
+	if err := registerer.Register(intervalCounter.intervalTotal); err != nil {
+		registerer.Unregister(intervalCounter.counterVec)
+		return nil, err
+	}
+
+	go intervalCounter.run(resetInterval)
+	return intervalCounter, nil
+}
+
+// Count of a single series during the current interval
+type intervalCount struct {
+	labelValues []string
+	total       float64
+}
+
+// Inc increments the counter with the given label values by 1
+func (intervalCounter *IntervalCounter) Inc(labelValues ...string) error {
+	return intervalCounter.Add(1, labelValues...)
+}
+
+// Add adds value to the counter with the given label values
+func (intervalCounter *IntervalCounter) Add(value float64, labelValues ...string) error {
+	intervalCounter.lock.Lock()
+	defer intervalCounter.lock.Unlock()
+
+	counter, err := intervalCounter.counterVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		return err
+	}
+	counter.Add(value)
+
+	key := strings.Join(labelValues, "\xff")
+	count, found := intervalCounter.counts[key]
+	if !found {
+		count = &intervalCount{labelValues: append([]string(nil), labelValues...)}
+		intervalCounter.counts[key] = count
+	}
+	count.total += value
+	return nil
+}
+
+// Stop stops resetting the counter and unregisters both metrics
+func (intervalCounter *IntervalCounter) Stop() {
+	intervalCounter.stopOnce.Do(func() {
+		close(intervalCounter.stop)
+		intervalCounter.registerer.Unregister(intervalCounter.counterVec)
+		intervalCounter.registerer.Unregister(intervalCounter.intervalTotal)
+	})
+}
+
+func (intervalCounter *IntervalCounter) run(resetInterval time.Duration) {
+	ticker := time.NewTicker(resetInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			intervalCounter.reset()
+		case <-intervalCounter.stop:
+			return
+		}
+	}
+}
+
+// Publishes each series' count to the interval total gauge and resets the
+// counter, keeping every series seen so far at 0. Holding the lock keeps Add
+// from counting into the closed interval.
+func (intervalCounter *IntervalCounter) reset() {
+	intervalCounter.lock.Lock()
+	defer intervalCounter.lock.Unlock()
+
+	intervalCounter.counterVec.Reset()
+	for _, count := range intervalCounter.counts {
+		intervalCounter.intervalTotal.WithLabelValues(count.labelValues...).Set(count.total)
+		intervalCounter.counterVec.WithLabelValues(count.labelValues...)
+		count.total = 0
+	}
+}
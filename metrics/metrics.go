@@ -1,9 +1,11 @@
 package metrics
 
 import (
+	"crypto/tls"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/bottlenose-inc/go-common-tools/logger"      // go-common-tools logger package
 	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
@@ -17,9 +19,17 @@ type PrometheusId struct {
 }
 
 var (
-	histogramBuckets = []float64{0.001, 0.0025, 0.005, 0.0075, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 45, 60, 90}
+	summaryObjectives = map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001}
+	histogramBuckets  = []float64{0.001, 0.0025, 0.005, 0.0075, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 45, 60, 90}
+
+	// ErrAlreadyRegistered is returned by the Create* functions when a metric
+	// of a different type is registered with the same name
+	ErrAlreadyRegistered = errors.New("A metric of a different type is already registered with this name")
 )
 
+// StartPrometheusMetricsServer serves the global registry on /metrics of the
+// default ServeMux, blocking until the server fails. Must only be called once.
+// StartPrometheusMetricsServerAsync runs a server in the background instead.
 func StartPrometheusMetricsServer(name string, logger *logger.Logger, port int) error {
 	// name for identifying the service
 	// logger - Logger object from go-common-tools#logger.go
@@ -36,7 +46,37 @@ func StartPrometheusMetricsServer(name string, logger *logger.Logger, port int)
 	return nil
 }
 
-func CreateHistogram(name string, namespace string, subsystem string, help string, labels map[string]string, buckets ...[]float64) (histogram prometheus.Histogram, err error) {
+// StartPrometheusMetricsServerTLS is like StartPrometheusMetricsServer but
+// serves HTTPS with the certificate and key in certFile and keyFile, and the
+// first tlsConfig if given (e.g. for client authentication). Uses its own
+// ServeMux rather than the default one, so it can be called alongside
+// StartPrometheusMetricsServer.
+func StartPrometheusMetricsServerTLS(name string, logger *logger.Logger, port int, certFile string, keyFile string, tlsConfig ...*tls.Config) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler())
+	server := &http.Server{Addr: ":" + strconv.Itoa(port), Handler: mux}
+	if len(tlsConfig) > 0 {
+		server.TLSConfig = tlsConfig[0]
+	}
+
+	err := server.ListenAndServeTLS(certFile, keyFile)
+	if err != nil {
+		logger.Error("Error starting Prometheus metrics server: " + err.Error())
+		return err
+	}
+	return nil
+}
+
+// CreateHistogram creates and registers a histogram with the global registry.
+// Safe for concurrent use, creating an identical metric again returns
+// the registered one.
+func CreateHistogram(name string, namespace string, subsystem string, help string, labels map[string]string, buckets ...[]float64) (prometheus.Histogram, error) {
+	return defaultRegistry.CreateHistogram(name, namespace, subsystem, help, labels, buckets...)
+}
+
+// CreateHistogram is like the package-level CreateHistogram, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateHistogram(name string, namespace string, subsystem string, help string, labels map[string]string, buckets ...[]float64) (histogram prometheus.Histogram, err error) {
 	// "name" and "help" are required by Prometheus to create a histogram
 	// all other fields are optional
 	// Returns a prometheus histogram object
@@ -51,6 +91,9 @@ func CreateHistogram(name string, namespace string, subsystem string, help strin
 		err = errors.New("Prometheus histogram requires both name and help fields to initialize - missing one or both of those fields")
 		return nil, err
 	}
+	if err = validateNames(namespace, subsystem, name, labels, nil); err != nil {
+		return nil, err
+	}
 	histogram = prometheus.NewHistogram(prometheus.HistogramOpts{
 		Name:        name,
 		Help:        help,
@@ -60,13 +103,29 @@ func CreateHistogram(name string, namespace string, subsystem string, help strin
 		Buckets:     useBuckets,
 	})
 
-	prometheus.MustRegister(histogram)
+	registered, err := metricsRegistry.register("histogram", histogram, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	histogram, ok := registered.(prometheus.Histogram)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
 
 	return histogram, nil
 
 }
 
-func CreateHistogramVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, buckets ...[]float64) (histogramVec *prometheus.HistogramVec, err error) {
+// CreateHistogramVector creates and registers a histogram vector with the
+// global registry. Safe for concurrent use, creating an identical metric
+// again returns the registered one.
+func CreateHistogramVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, buckets ...[]float64) (*prometheus.HistogramVec, error) {
+	return defaultRegistry.CreateHistogramVector(name, namespace, subsystem, help, labels, labelNames, buckets...)
+}
+
+// CreateHistogramVector is like the package-level CreateHistogramVector, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateHistogramVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, buckets ...[]float64) (histogramVec *prometheus.HistogramVec, err error) {
 	// "name" and "help" are required by Prometheus to create a histogram
 	// all other fields are optional
 	// Returns a prometheus histogram object
@@ -81,6 +140,9 @@ func CreateHistogramVector(name string, namespace string, subsystem string, help
 		err = errors.New("Prometheus histogram requires both name and help fields to initialize - missing one or both of those fields")
 		return nil, err
 	}
+	if err = validateNames(namespace, subsystem, name, labels, labelNames); err != nil {
+		return nil, err
+	}
 	histogramVec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name:        name,
 		Help:        help,
@@ -90,12 +152,125 @@ func CreateHistogramVector(name string, namespace string, subsystem string, help
 		Buckets:     useBuckets,
 	}, labelNames)
 
-	prometheus.MustRegister(histogramVec)
+	registered, err := metricsRegistry.register("histogram_vector", histogramVec, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	histogramVec, ok := registered.(*prometheus.HistogramVec)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
 
 	return histogramVec, nil
 
 }
-func CreateCounterVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (counterVec *prometheus.CounterVec, err error) {
+
+// CreateSummary creates and registers a summary with the global registry,
+// tracking the 50th, 90th and 99th percentiles unless objectives are given.
+// Safe for concurrent use, creating an identical summary again returns the
+// registered one.
+func CreateSummary(name string, namespace string, subsystem string, help string, labels map[string]string, objectives map[float64]float64) (prometheus.Summary, error) {
+	return defaultRegistry.CreateSummary(name, namespace, subsystem, help, labels, objectives)
+}
+
+// CreateSummary is like the package-level CreateSummary, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateSummary(name string, namespace string, subsystem string, help string, labels map[string]string, objectives map[float64]float64) (summary prometheus.Summary, err error) {
+	// "name" and "help" are required by Prometheus to create a summary
+	// all other fields are optional
+	// Returns a prometheus summary object
+
+	if objectives == nil {
+		objectives = summaryObjectives
+	}
+
+	constLabels := prometheus.Labels(labels)
+	if name == "" || help == "" {
+		err = errors.New("Prometheus summary requires both name and help fields to initialize - missing one or both of those fields")
+		return nil, err
+	}
+	if err = validateNames(namespace, subsystem, name, labels, nil); err != nil {
+		return nil, err
+	}
+	summary = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name:        name,
+		Help:        help,
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		ConstLabels: constLabels,
+		Objectives:  objectives,
+	})
+
+	registered, err := metricsRegistry.register("summary", summary, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	summary, ok := registered.(prometheus.Summary)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
+
+	return summary, nil
+}
+
+// CreateSummaryVector creates and registers a summary vector with the global
+// registry, tracking the 50th, 90th and 99th percentiles unless objectives
+// are given. Safe for concurrent use, creating an identical summary vector
+// again returns the registered one.
+func CreateSummaryVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, objectives map[float64]float64) (*prometheus.SummaryVec, error) {
+	return defaultRegistry.CreateSummaryVector(name, namespace, subsystem, help, labels, labelNames, objectives)
+}
+
+// CreateSummaryVector is like the package-level CreateSummaryVector, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateSummaryVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string, objectives map[float64]float64) (summaryVec *prometheus.SummaryVec, err error) {
+	// "name" and "help" are required by Prometheus to create a summary
+	// all other fields are optional
+	// Returns a prometheus summary vector object
+
+	if objectives == nil {
+		objectives = summaryObjectives
+	}
+
+	constLabels := prometheus.Labels(labels)
+	if name == "" || help == "" {
+		err = errors.New("Prometheus summary vector requires both name and help fields to initialize - missing one or both of those fields")
+		return nil, err
+	}
+	if err = validateNames(namespace, subsystem, name, labels, labelNames); err != nil {
+		return nil, err
+	}
+	summaryVec = prometheus.NewSummaryVec(prometheus.SummaryOpts{
+		Name:        name,
+		Help:        help,
+		Namespace:   namespace,
+		Subsystem:   subsystem,
+		ConstLabels: constLabels,
+		Objectives:  objectives,
+	}, labelNames)
+
+	registered, err := metricsRegistry.register("summary_vector", summaryVec, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	summaryVec, ok := registered.(*prometheus.SummaryVec)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
+
+	return summaryVec, nil
+}
+
+// CreateCounterVector creates and registers a counter vector with the global
+// registry. Safe for concurrent use, creating an identical metric again returns
+// the registered one.
+func CreateCounterVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.CounterVec, error) {
+	return defaultRegistry.CreateCounterVector(name, namespace, subsystem, help, labels, labelNames)
+}
+
+// CreateCounterVector is like the package-level CreateCounterVector, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateCounterVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (counterVec *prometheus.CounterVec, err error) {
 	// "name" and "help" are required by Prometheus to create a counter vector
 	// all other fields are optional
 	// Returns a prometheus counter vector object
@@ -106,6 +281,9 @@ func CreateCounterVector(name string, namespace string, subsystem string, help s
 		err = errors.New("Prometheus counter vector requires both name and help fields to initialize - missing one or both of those fields")
 		return nil, err
 	}
+	if err = validateNames(namespace, subsystem, name, labels, labelNames); err != nil {
+		return nil, err
+	}
 	counterVec = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name:        name,
 		Help:        help,
@@ -114,12 +292,20 @@ func CreateCounterVector(name string, namespace string, subsystem string, help s
 		ConstLabels: constLabels,
 	}, labelNames)
 
-	prometheus.MustRegister(counterVec)
+	registered, err := metricsRegistry.register("counter_vector", counterVec, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	counterVec, ok := registered.(*prometheus.CounterVec)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
 
 	return counterVec, nil
 }
 
 // initialize a counter vector with given labels, setting values to 0
+// Safe for concurrent use, as are all Prometheus metric operations
 func InitCounterVector(counterVec *prometheus.CounterVec, labels []string) {
 	for _, label := range labels {
 		counter, err := counterVec.GetMetricWithLabelValues(label)
@@ -129,7 +315,33 @@ func InitCounterVector(counterVec *prometheus.CounterVec, labels []string) {
 	}
 }
 
-func CreateCounter(name string, namespace string, subsystem string, help string, labels map[string]string) (counter prometheus.Counter, err error) {
+// initialize a gauge vector with given labels, setting values to 0
+// Safe for concurrent use, as are all Prometheus metric operations
+func InitGaugeVector(gaugeVec *prometheus.GaugeVec, labels []string) {
+	InitGaugeVectorWithValue(gaugeVec, labels, 0)
+}
+
+// initialize a gauge vector with given labels, setting values to value
+// Safe for concurrent use, as are all Prometheus metric operations
+func InitGaugeVectorWithValue(gaugeVec *prometheus.GaugeVec, labels []string, value float64) {
+	for _, label := range labels {
+		gauge, err := gaugeVec.GetMetricWithLabelValues(label)
+		if err == nil {
+			gauge.Set(value)
+		}
+	}
+}
+
+// CreateCounter creates and registers a counter with the global registry.
+// Safe for concurrent use, creating an identical metric again returns
+// the registered one.
+func CreateCounter(name string, namespace string, subsystem string, help string, labels map[string]string) (prometheus.Counter, error) {
+	return defaultRegistry.CreateCounter(name, namespace, subsystem, help, labels)
+}
+
+// CreateCounter is like the package-level CreateCounter, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateCounter(name string, namespace string, subsystem string, help string, labels map[string]string) (counter prometheus.Counter, err error) {
 	// "name" and "help" are required by Prometheus to create a counter
 	// all other fields are optional
 	// Returns a prometheus counter object
@@ -140,6 +352,9 @@ func CreateCounter(name string, namespace string, subsystem string, help string,
 		err = errors.New("Prometheus counter requires both name and help fields to initialize - missing one or both of those fields")
 		return nil, err
 	}
+	if err = validateNames(namespace, subsystem, name, labels, nil); err != nil {
+		return nil, err
+	}
 
 	counter = prometheus.NewCounter(prometheus.CounterOpts{
 		Name:        name,
@@ -149,12 +364,28 @@ func CreateCounter(name string, namespace string, subsystem string, help string,
 		ConstLabels: constLabels,
 	})
 
-	prometheus.MustRegister(counter)
+	registered, err := metricsRegistry.register("counter", counter, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	counter, ok := registered.(prometheus.Counter)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
 
 	return counter, nil
 }
 
-func CreateGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (gauge prometheus.Gauge, err error) {
+// CreateGauge creates and registers a gauge with the global registry.
+// Safe for concurrent use, creating an identical metric again returns
+// the registered one.
+func CreateGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (prometheus.Gauge, error) {
+	return defaultRegistry.CreateGauge(name, namespace, subsystem, help, labels)
+}
+
+// CreateGauge is like the package-level CreateGauge, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateGauge(name string, namespace string, subsystem string, help string, labels map[string]string) (gauge prometheus.Gauge, err error) {
 	// "name" and "help" are required by Prometheus to create a gauge
 	// all other fields are optional
 	// Returns a prometheus gauge object
@@ -165,6 +396,9 @@ func CreateGauge(name string, namespace string, subsystem string, help string, l
 		err = errors.New("Prometheus gauge requires both name and help fields to initialize - missing one or both of those fields")
 		return nil, err
 	}
+	if err = validateNames(namespace, subsystem, name, labels, nil); err != nil {
+		return nil, err
+	}
 
 	gauge = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name:        name,
@@ -174,12 +408,35 @@ func CreateGauge(name string, namespace string, subsystem string, help string, l
 		ConstLabels: constLabels,
 	})
 
-	prometheus.MustRegister(gauge)
+	registered, err := metricsRegistry.register("gauge", gauge, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, err
+	}
+	gauge, ok := registered.(prometheus.Gauge)
+	if !ok {
+		return nil, ErrAlreadyRegistered
+	}
 
 	return gauge, nil
 }
 
-func CreateGaugeVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (gaugeVec *prometheus.GaugeVec, err error) {
+// CreateGaugeVector creates and registers a gauge vector with the global
+// registry. Safe for concurrent use, creating an identical metric again returns
+// the registered one.
+func CreateGaugeVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.GaugeVec, error) {
+	return defaultRegistry.CreateGaugeVector(name, namespace, subsystem, help, labels, labelNames)
+}
+
+// CreateGaugeVector is like the package-level CreateGaugeVector, but registers with
+// metricsRegistry
+func (metricsRegistry *MetricsRegistry) CreateGaugeVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (*prometheus.GaugeVec, error) {
+	gaugeVec, _, err := metricsRegistry.createGaugeVector(name, namespace, subsystem, help, labels, labelNames)
+	return gaugeVec, err
+}
+
+// Creates a gauge vector like CreateGaugeVector, also returning whether it was
+// newly registered rather than an identical existing one
+func (metricsRegistry *MetricsRegistry) createGaugeVector(name string, namespace string, subsystem string, help string, labels map[string]string, labelNames []string) (gaugeVec *prometheus.GaugeVec, created bool, err error) {
 	// "name" and "help" are required by Prometheus to create a gauge vector
 	// all other fields are optional
 	// Returns a prometheus gauge vector object
@@ -188,7 +445,10 @@ func CreateGaugeVector(name string, namespace string, subsystem string, help str
 
 	if name == "" || help == "" {
 		err = errors.New("Prometheus gauge vector requires both name and help fields to initialize - missing one or both of those fields")
-		return nil, err
+		return nil, false, err
+	}
+	if err = validateNames(namespace, subsystem, name, labels, labelNames); err != nil {
+		return nil, false, err
 	}
 
 	gaugeVec = prometheus.NewGaugeVec(prometheus.GaugeOpts{
@@ -199,7 +459,63 @@ func CreateGaugeVector(name string, namespace string, subsystem string, help str
 		ConstLabels: constLabels,
 	}, labelNames)
 
-	prometheus.MustRegister(gaugeVec)
+	registered, err := metricsRegistry.register("gauge_vector", gaugeVec, name, namespace, subsystem, labels)
+	if err != nil {
+		return nil, false, err
+	}
+	created = registered == prometheus.Collector(gaugeVec)
+	gaugeVec, ok := registered.(*prometheus.GaugeVec)
+	if !ok {
+		return nil, false, ErrAlreadyRegistered
+	}
+
+	return gaugeVec, created, nil
+}
+
+// PartialHistogramVec is a view of a HistogramVec with some label values
+// fixed, so sub-components only need to supply the remaining labels. Safe
+// for concurrent use.
+type PartialHistogramVec struct {
+	histogramVec *prometheus.HistogramVec
+	fixedLabels  prometheus.Labels
+}
 
-	return gaugeVec, nil
+func NewPartialHistogramVec(histogramVec *prometheus.HistogramVec, fixedLabels prometheus.Labels) *PartialHistogramVec {
+	return &PartialHistogramVec{histogramVec: histogramVec, fixedLabels: fixedLabels}
+}
+
+// Observe merges the fixed labels with variableLabels and observes value in
+// the resulting histogram. Returns an error if the merged labels do not match
+// the HistogramVec's label names.
+func (partialVec *PartialHistogramVec) Observe(value float64, variableLabels prometheus.Labels) error {
+	merged := make(prometheus.Labels, len(partialVec.fixedLabels)+len(variableLabels))
+	for name, labelValue := range partialVec.fixedLabels {
+		merged[name] = labelValue
+	}
+	for name, labelValue := range variableLabels {
+		merged[name] = labelValue
+	}
+
+	histogram, err := partialVec.histogramVec.GetMetricWith(merged)
+	if err != nil {
+		return err
+	}
+	histogram.Observe(value)
+	return nil
+}
+
+// ObserveSince observes the seconds elapsed since start in histogram
+func ObserveSince(histogram prometheus.Histogram, start time.Time) {
+	histogram.Observe(time.Since(start).Seconds())
+}
+
+// ObserveSinceVec observes the seconds elapsed since start in the histogram
+// with the given label values
+func ObserveSinceVec(histogramVec *prometheus.HistogramVec, start time.Time, labels ...string) error {
+	histogram, err := histogramVec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		return err
+	}
+	histogram.Observe(time.Since(start).Seconds())
+	return nil
 }
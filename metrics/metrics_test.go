@@ -0,0 +1,665 @@
+package metrics
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bottlenose-inc/go-common-tools/logger"        // go-common-tools logger package
+	"github.com/prometheus/client_golang/prometheus"          // Official Prometheus golang library
+	"github.com/prometheus/client_golang/prometheus/testutil" // Prometheus testing helpers
+	dto "github.com/prometheus/client_model/go"               // Prometheus metric data model
+	"github.com/stretchr/testify/assert"                      // Assertion package
+)
+
+// Returns the sample count and sum of a histogram
+func histogramValues(t *testing.T, histogram prometheus.Observer) (uint64, float64) {
+	var metric dto.Metric
+	if err := histogram.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatalf("Error writing histogram: %s", err.Error())
+	}
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum()
+}
+
+func TestPartialHistogramVec(t *testing.T) {
+	histogramVec, err := CreateHistogramVector("partial_histogram_test", "", "", "Test histogram", nil, []string{"component", "operation"})
+	assert.Nil(t, err)
+
+	partialVec := NewPartialHistogramVec(histogramVec, prometheus.Labels{"component": "cache"})
+	assert.Nil(t, partialVec.Observe(0.5, prometheus.Labels{"operation": "get"}))
+	assert.Nil(t, partialVec.Observe(1.5, prometheus.Labels{"operation": "get"}))
+	assert.NotNil(t, partialVec.Observe(1, prometheus.Labels{"unknown": "label"}))
+
+	count, sum := histogramValues(t, histogramVec.WithLabelValues("cache", "get"))
+	assert.Equal(t, uint64(2), count)
+	assert.Equal(t, 2.0, sum)
+}
+
+func TestDescribeAll(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "describe_counter", Help: "Test counter"})
+	gaugeVec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "describe_gauge", Help: "Test gauge"}, []string{"queue"})
+	registry.MustRegister(counter, gaugeVec)
+	counter.Add(3)
+	gaugeVec.WithLabelValues("jobs").Set(1.5)
+
+	assert.Equal(t, "counter describe_counter - Test counter [3]\ngauge describe_gauge{queue=\"jobs\"} - Test gauge [1.5]", DescribeAll(registry))
+
+	gaugeVec.WithLabelValues("mail").Set(2)
+	assert.Equal(t, "counter describe_counter - Test counter [3]\n... 2 more", DescribeAllLimit(registry, 1))
+	assert.Equal(t, 3, len(strings.Split(DescribeAllLimit(registry, 0), "\n")))
+
+	_, err := CreateCounter("describe_global_counter", "", "", "Global test counter", nil)
+	assert.Nil(t, err)
+	assert.Contains(t, DescribeAll(nil), "counter describe_global_counter - Global test counter [0]")
+}
+
+func TestHighWaterMarkGauge(t *testing.T) {
+	hwg, err := NewHighWaterMarkGauge("active_connections_test", "", "", "Test connections", nil)
+	assert.Nil(t, err)
+
+	hwg.Set(5)
+	hwg.Set(12)
+	hwg.Set(3)
+	assert.Equal(t, 3.0, testutil.ToFloat64(hwg.gauge))
+	assert.Equal(t, 12.0, testutil.ToFloat64(hwg.highWaterGauge))
+
+	hwg.ResetHighWaterMark()
+	assert.Equal(t, 3.0, testutil.ToFloat64(hwg.highWaterGauge))
+	hwg.Set(4)
+	assert.Equal(t, 4.0, testutil.ToFloat64(hwg.highWaterGauge))
+
+	_, err = NewHighWaterMarkGauge("", "", "", "", nil)
+	assert.NotNil(t, err)
+
+	// The first value sets the mark, even if negative
+	negative, err := NewHighWaterMarkGauge("temperature_test", "", "", "Test temperature", map[string]string{"room": "a"})
+	assert.Nil(t, err)
+	negative.Set(-5)
+	assert.Equal(t, -5.0, testutil.ToFloat64(negative.highWaterGauge))
+
+	// Gauges with other label values share the vectors
+	other, err := NewHighWaterMarkGauge("temperature_test", "", "", "Test temperature", map[string]string{"room": "b"})
+	assert.Nil(t, err)
+	other.Set(20)
+	assert.Equal(t, -5.0, testutil.ToFloat64(negative.highWaterGauge))
+	assert.Equal(t, 20.0, testutil.ToFloat64(other.highWaterGauge))
+}
+
+func TestHighWaterMarkGaugeConflict(t *testing.T) {
+	_, err := CreateCounter("queue_depth_test_high_water_mark", "", "", "Test conflict", nil)
+	assert.Nil(t, err)
+
+	// A gauge vector created by the failed call is unregistered
+	_, err = NewHighWaterMarkGauge("queue_depth_test", "", "", "Test queue depth", nil)
+	assert.NotNil(t, err)
+	_, err = CreateCounter("queue_depth_test", "", "", "Test queue depth", nil)
+	assert.Nil(t, err)
+
+	// An existing gauge vector is left registered
+	existing, err := CreateGaugeVector("pool_size_test", "", "", "Test pool size", nil, nil)
+	assert.Nil(t, err)
+	_, err = CreateCounter("pool_size_test_high_water_mark", "", "", "Test conflict", nil)
+	assert.Nil(t, err)
+	_, err = NewHighWaterMarkGauge("pool_size_test", "", "", "Test pool size", nil)
+	assert.NotNil(t, err)
+	registered, err := CreateGaugeVector("pool_size_test", "", "", "Test pool size", nil, nil)
+	assert.Nil(t, err)
+	assert.True(t, existing == registered)
+}
+
+func TestBoolGauge(t *testing.T) {
+	boolGauge, err := CreateBoolGauge("cache_warm_test", "", "", "Test cache state", nil)
+	assert.Nil(t, err)
+	assert.False(t, boolGauge.IsEnabled())
+	assert.Equal(t, 0.0, testutil.ToFloat64(boolGauge.gauge))
+
+	boolGauge.Enable()
+	assert.True(t, boolGauge.IsEnabled())
+	assert.Equal(t, 1.0, testutil.ToFloat64(boolGauge.gauge))
+	boolGauge.Disable()
+	assert.False(t, boolGauge.IsEnabled())
+	boolGauge.Set(true)
+	assert.Equal(t, 1.0, testutil.ToFloat64(boolGauge.gauge))
+	boolGauge.Set(false)
+	assert.Equal(t, 0.0, testutil.ToFloat64(boolGauge.gauge))
+
+	_, err = CreateBoolGauge("", "", "", "", nil)
+	assert.NotNil(t, err)
+}
+
+func TestIntervalCounter(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	intervalCounter, err := NewIntervalCounter("events", "", "", "Test events", []string{"type"}, time.Hour, registry)
+	assert.Nil(t, err)
+	defer intervalCounter.Stop()
+
+	assert.Nil(t, intervalCounter.Inc("click"))
+	assert.Nil(t, intervalCounter.Add(2, "click"))
+	assert.Nil(t, intervalCounter.Inc("view"))
+	assert.NotNil(t, intervalCounter.Inc("too", "many"))
+	assert.Equal(t, 3.0, testutil.ToFloat64(intervalCounter.counterVec.WithLabelValues("click")))
+
+	intervalCounter.reset()
+	assert.Equal(t, 3.0, testutil.ToFloat64(intervalCounter.intervalTotal.WithLabelValues("click")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(intervalCounter.intervalTotal.WithLabelValues("view")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(intervalCounter.counterVec.WithLabelValues("click")))
+
+	// Series without events in the next interval read 0 instead of vanishing
+	assert.Nil(t, intervalCounter.Inc("click"))
+	intervalCounter.reset()
+	families, err := registry.Gather()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(families))
+	for _, family := range families {
+		assert.Equal(t, 2, len(family.GetMetric()), family.GetName())
+	}
+	assert.Equal(t, 1.0, testutil.ToFloat64(intervalCounter.intervalTotal.WithLabelValues("click")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(intervalCounter.intervalTotal.WithLabelValues("view")))
+
+	_, err = NewIntervalCounter("events", "", "", "Test events", []string{"type"}, time.Hour, registry)
+	assert.NotNil(t, err)
+
+	// Stop unregisters both metrics, so the counter can be created again
+	intervalCounter.Stop()
+	recreated, err := NewIntervalCounter("events", "", "", "Test events", []string{"type"}, time.Hour, registry)
+	assert.Nil(t, err)
+	recreated.Stop()
+}
+
+func TestObserveSince(t *testing.T) {
+	histogram, err := CreateHistogram("observe_since_test", "", "", "Test histogram", nil)
+	assert.Nil(t, err)
+	ObserveSince(histogram, time.Now().Add(-2*time.Second))
+	count, sum := histogramValues(t, histogram)
+	assert.Equal(t, uint64(1), count)
+	assert.InDelta(t, 2, sum, 0.5)
+
+	histogramVec, err := CreateHistogramVector("observe_since_vec_test", "", "", "Test histogram", nil, []string{"operation"})
+	assert.Nil(t, err)
+	assert.Nil(t, ObserveSinceVec(histogramVec, time.Now().Add(-time.Second), "get"))
+	assert.NotNil(t, ObserveSinceVec(histogramVec, time.Now(), "get", "extra"))
+	count, sum = histogramValues(t, histogramVec.WithLabelValues("get"))
+	assert.Equal(t, uint64(1), count)
+	assert.InDelta(t, 1, sum, 0.5)
+}
+
+func TestCreationAudit(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "audit.log")
+	log, err := logger.NewLogger("audit", logPath)
+	assert.Nil(t, err)
+	EnableCreationAudit(log)
+	defer EnableCreationAudit(nil)
+
+	_, err = CreateCounter("audited_counter", "test", "audit", "Audited counter", map[string]string{"env": "test"})
+	assert.Nil(t, err)
+	log.Close()
+
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	assert.Contains(t, string(raw), `"metric_name":"test_audit_audited_counter"`)
+	assert.Contains(t, string(raw), `"metric_type":"counter"`)
+	assert.Contains(t, string(raw), `"level":20`)
+
+	found := false
+	for _, description := range ListRegistered(nil) {
+		if description.Name == "test_audit_audited_counter" {
+			found = true
+			assert.Equal(t, MetricDescription{
+				Name:        "test_audit_audited_counter",
+				Type:        "counter",
+				Help:        "Audited counter",
+				ConstLabels: map[string]string{"env": "test"},
+			}, description)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestHelpServer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	counterVec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "help_requests_total", Help: "Requests handled"}, []string{"code", "method"})
+	registry.MustRegister(counterVec)
+	counterVec.WithLabelValues("200", "GET").Inc()
+
+	server := NewMetricsServer(nil, 0, "")
+	help := NewHelpServer(server)
+	help.Gatherer = registry
+
+	recorder := httptest.NewRecorder()
+	server.Mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics-help?format=json", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	var descriptions []metricHelp
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &descriptions))
+	assert.Equal(t, []metricHelp{{Name: "help_requests_total", Type: "counter", Help: "Requests handled", Labels: []string{"code", "method"}}}, descriptions)
+
+	recorder = httptest.NewRecorder()
+	server.Mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics-help", nil))
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Contains(t, recorder.Body.String(), "<td>help_requests_total</td><td>counter</td><td>Requests handled</td><td>code, method</td>")
+}
+
+func TestConcurrentCreate(t *testing.T) {
+	var wg sync.WaitGroup
+	gauges := make([]prometheus.Gauge, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_, err := CreateCounter("concurrent_counter_"+strconv.Itoa(i), "test", "", "Concurrently created counter", nil)
+			assert.Nil(t, err)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			gauge, err := CreateGauge("concurrent_gauge", "test", "", "Gauge created by every goroutine", nil)
+			assert.Nil(t, err)
+			gauges[i] = gauge
+		}(i)
+	}
+	wg.Wait()
+	for _, gauge := range gauges {
+		assert.True(t, gauge == gauges[0])
+	}
+}
+
+func TestCreateRegistered(t *testing.T) {
+	counter, err := CreateCounter("reregistered_counter", "test", "", "Counter created twice", nil)
+	assert.Nil(t, err)
+	again, err := CreateCounter("reregistered_counter", "test", "", "Counter created twice", nil)
+	assert.Nil(t, err)
+	assert.True(t, counter == again)
+	counter.Inc()
+	assert.Equal(t, 1.0, testutil.ToFloat64(again))
+
+	counterVec, err := CreateCounterVector("reregistered_counter_vec", "test", "", "Counter vector created twice", nil, []string{"code"})
+	assert.Nil(t, err)
+	againVec, err := CreateCounterVector("reregistered_counter_vec", "test", "", "Counter vector created twice", nil, []string{"code"})
+	assert.Nil(t, err)
+	assert.True(t, counterVec == againVec)
+
+	_, err = CreateGauge("reregistered_counter", "test", "", "Counter created twice", nil)
+	assert.Equal(t, ErrAlreadyRegistered, err)
+	_, err = CreateCounter("reregistered_counter", "test", "", "Different help", nil)
+	assert.NotNil(t, err)
+}
+
+func TestCreateSummary(t *testing.T) {
+	summary, err := CreateSummary("summary_test", "test", "", "Test summary", nil, nil)
+	assert.Nil(t, err)
+	for i := 1; i <= 100; i++ {
+		summary.Observe(float64(i))
+	}
+
+	var metric dto.Metric
+	assert.Nil(t, summary.(prometheus.Metric).Write(&metric))
+	assert.Equal(t, uint64(100), metric.GetSummary().GetSampleCount())
+	assert.Equal(t, 5050.0, metric.GetSummary().GetSampleSum())
+	quantiles := map[float64]float64{}
+	for _, quantile := range metric.GetSummary().GetQuantile() {
+		quantiles[quantile.GetQuantile()] = quantile.GetValue()
+	}
+	assert.Equal(t, 3, len(quantiles))
+	assert.InDelta(t, 50, quantiles[0.5], 5)
+	assert.InDelta(t, 99, quantiles[0.99], 1)
+
+	_, err = CreateSummary("", "test", "", "Test summary", nil, nil)
+	assert.NotNil(t, err)
+
+	summaryVec, err := CreateSummaryVector("summary_vec_test", "test", "", "Test summary", nil, []string{"operation"}, map[float64]float64{0.75: 0.01})
+	assert.Nil(t, err)
+	summaryVec.WithLabelValues("get").Observe(2)
+	metric.Reset()
+	assert.Nil(t, summaryVec.WithLabelValues("get").(prometheus.Metric).Write(&metric))
+	assert.Equal(t, uint64(1), metric.GetSummary().GetSampleCount())
+	assert.Equal(t, 0.75, metric.GetSummary().GetQuantile()[0].GetQuantile())
+
+}
+
+func TestMetricsRegistry(t *testing.T) {
+	isolated := NewMetricsRegistry()
+	counter, err := isolated.CreateCounter("isolated_counter", "test", "", "Isolated counter", nil)
+	assert.Nil(t, err)
+	counter.Add(2)
+	histogramVec, err := isolated.CreateHistogramVector("isolated_histogram", "test", "", "Isolated histogram", nil, []string{"operation"})
+	assert.Nil(t, err)
+	histogramVec.WithLabelValues("get").Observe(1)
+
+	// Registering the same names in another registry doesn't conflict
+	other, err := NewMetricsRegistry().CreateCounter("isolated_counter", "test", "", "Isolated counter", nil)
+	assert.Nil(t, err)
+	assert.False(t, counter == other)
+
+	names := []string{}
+	for _, description := range ListRegistered(isolated.Registry) {
+		names = append(names, description.Name)
+	}
+	assert.Equal(t, []string{"test_isolated_counter", "test_isolated_histogram"}, names)
+	for _, description := range ListRegistered(nil) {
+		assert.NotEqual(t, "test_isolated_counter", description.Name)
+	}
+	assert.Equal(t, 2.0, testutil.ToFloat64(counter))
+
+	// Const labels are tracked per registry
+	_, err = isolated.CreateGauge("labelled_gauge", "test", "", "Labelled gauge", map[string]string{"env": "isolated"})
+	assert.Nil(t, err)
+	_, err = CreateGauge("labelled_gauge", "test", "", "Labelled gauge", map[string]string{"env": "global"})
+	assert.Nil(t, err)
+	for _, description := range isolated.ListRegistered() {
+		if description.Name == "test_labelled_gauge" {
+			assert.Equal(t, map[string]string{"env": "isolated"}, description.ConstLabels)
+		}
+	}
+	for _, description := range ListRegistered(isolated.Registry) {
+		assert.Nil(t, description.ConstLabels)
+	}
+	for _, description := range ListRegistered(nil) {
+		if description.Name == "test_labelled_gauge" {
+			assert.Equal(t, map[string]string{"env": "global"}, description.ConstLabels)
+		}
+	}
+}
+
+func TestStartPrometheusMetricsServerAsync(t *testing.T) {
+	log, _ := logger.NewTestLogger("metrics")
+	ctx, cancel := context.WithCancel(context.Background())
+	id, errs := StartPrometheusMetricsServerAsync(ctx, "async", log, 0)
+	assert.NotNil(t, id)
+	assert.Equal(t, "async", id.Name)
+	assert.NotEqual(t, 0, id.Port)
+	assert.Contains(t, RunningPrometheusServers(), *id)
+
+	resp, err := http.Get("http://127.0.0.1:" + strconv.Itoa(id.Port) + "/metrics")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Binding the same port fails
+	failedId, failedErrs := StartPrometheusMetricsServerAsync(context.Background(), "conflict", log, id.Port)
+	assert.Nil(t, failedId)
+	assert.NotNil(t, <-failedErrs)
+
+	cancel()
+	select {
+	case err, open := <-errs:
+		assert.Nil(t, err)
+		assert.False(t, open)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server did not shut down")
+	}
+	assert.NotContains(t, RunningPrometheusServers(), *id)
+}
+
+func TestTimer(t *testing.T) {
+	histogram, err := CreateHistogram("timer_test", "", "", "Test histogram", nil)
+	assert.Nil(t, err)
+	func() {
+		defer NewTimer(histogram).DeferObserveDuration()()
+		time.Sleep(10 * time.Millisecond)
+	}()
+	count, sum := histogramValues(t, histogram)
+	assert.Equal(t, uint64(1), count)
+	assert.InDelta(t, 0.01, sum, 0.01)
+
+	histogramVec, err := CreateHistogramVector("timer_vec_test", "", "", "Test histogram", nil, []string{"operation"})
+	assert.Nil(t, err)
+	timer := NewTimerVec(histogramVec, "get")
+	elapsed := timer.ObserveDuration()
+	count, sum = histogramValues(t, histogramVec.WithLabelValues("get"))
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, elapsed.Seconds(), sum)
+	assert.Panics(t, func() { NewTimerVec(histogramVec, "get", "extra") })
+}
+
+func TestBuckets(t *testing.T) {
+	buckets, err := ExponentialBuckets(0.001, 10, 4)
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{0.001, 0.01, 0.1, 1}, buckets, 1e-12)
+	buckets, err = ExponentialBuckets(100, 2, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{100}, buckets)
+	for _, args := range [][]float64{{0, 2, 3}, {-1, 2, 3}, {1, 1, 3}, {1, 0.5, 3}, {1, 2, 0}} {
+		_, err = ExponentialBuckets(args[0], args[1], int(args[2]))
+		assert.NotNil(t, err, "%v", args)
+	}
+
+	buckets, err = LinearBuckets(50, 25, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{50, 75, 100, 125}, buckets)
+	for _, args := range [][]float64{{0, 1, 3}, {1, 0, 3}, {1, -1, 3}, {1, 1, 0}} {
+		_, err = LinearBuckets(args[0], args[1], int(args[2]))
+		assert.NotNil(t, err, "%v", args)
+	}
+
+	histogram, err := CreateHistogram("custom_buckets_test", "", "", "Test histogram", nil, buckets)
+	assert.Nil(t, err)
+	histogram.Observe(80)
+	var metric dto.Metric
+	assert.Nil(t, histogram.(prometheus.Metric).Write(&metric))
+	assert.Equal(t, 4, len(metric.GetHistogram().GetBucket()))
+	assert.Equal(t, uint64(0), metric.GetHistogram().GetBucket()[0].GetCumulativeCount())
+	assert.Equal(t, uint64(1), metric.GetHistogram().GetBucket()[2].GetCumulativeCount())
+}
+
+func TestInitGaugeVector(t *testing.T) {
+	gaugeVec, err := CreateGaugeVector("init_gauge_test", "test", "", "Test gauge", nil, []string{"queue"})
+	assert.Nil(t, err)
+	InitGaugeVector(gaugeVec, []string{"jobs", "emails"})
+	flagVec, err := CreateGaugeVector("init_flag_test", "test", "", "Test feature flag", nil, []string{"feature"})
+	assert.Nil(t, err)
+	InitGaugeVectorWithValue(flagVec, []string{"search"}, 1)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	assert.Nil(t, err)
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			if family.GetName() == "test_init_gauge_test" || family.GetName() == "test_init_flag_test" {
+				values[family.GetName()+"/"+metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"test_init_gauge_test/jobs":   0,
+		"test_init_gauge_test/emails": 0,
+		"test_init_flag_test/search":  1,
+	}, values)
+}
+
+func TestValidateNames(t *testing.T) {
+	for _, name := range []string{"requests_total", "http:requests:rate5m", "_private", "A1"} {
+		assert.Nil(t, ValidateMetricName(name), name)
+	}
+	for name, message := range map[string]string{
+		"request count":  `Invalid metric name "request count": character ' ' at position 7 is not allowed`,
+		"request-count":  `Invalid metric name "request-count": character '-' at position 7 is not allowed`,
+		"5xx_responses":  `Invalid metric name "5xx_responses": character '5' at position 0 is not allowed`,
+		"":               "Invalid metric name: must not be empty",
+		"latency_µs_sum": `Invalid metric name "latency_µs_sum": character 'µ' at position 8 is not allowed`,
+	} {
+		err := ValidateMetricName(name)
+		if assert.NotNil(t, err, name) {
+			assert.Equal(t, message, err.Error())
+		}
+	}
+
+	assert.Nil(t, ValidateLabelName("status_code"))
+	assert.NotNil(t, ValidateLabelName("status:code"))
+	assert.NotNil(t, ValidateLabelName("status-code"))
+	assert.NotNil(t, ValidateLabelName("__name__"))
+
+	_, err := CreateCounter("invalid-counter", "test", "", "Invalid counter", nil)
+	assert.Contains(t, err.Error(), `Invalid metric name "test_invalid-counter"`)
+	_, err = CreateGaugeVector("invalid_labels", "test", "", "Invalid labels", map[string]string{"env": "test"}, []string{"queue name"})
+	assert.Contains(t, err.Error(), `Invalid label name "queue name"`)
+	_, err = CreateHistogram("invalid_const_labels", "test", "", "Invalid labels", map[string]string{"1env": "test"})
+	assert.Contains(t, err.Error(), `Invalid label name "1env"`)
+}
+
+func TestGetOrCreate(t *testing.T) {
+	counter, err := GetOrCreateCounter("get_or_create_counter", "test", "", "Cached counter", nil)
+	assert.Nil(t, err)
+	again, err := GetOrCreateCounter("get_or_create_counter", "test", "", "Cached counter", nil)
+	assert.Nil(t, err)
+	assert.True(t, counter == again)
+
+	registry := NewMetricsRegistry()
+	var wg sync.WaitGroup
+	histogramVecs := make([]*prometheus.HistogramVec, 10)
+	for i := range histogramVecs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			histogramVec, err := registry.GetOrCreateHistogramVector("get_or_create_histogram", "test", "", "Cached histogram", nil, []string{"operation"})
+			assert.Nil(t, err)
+			histogramVecs[i] = histogramVec
+		}(i)
+	}
+	wg.Wait()
+	for _, histogramVec := range histogramVecs {
+		assert.True(t, histogramVec == histogramVecs[0])
+	}
+
+	_, err = registry.GetOrCreateGauge("get_or_create_histogram", "test", "", "Cached histogram", nil)
+	assert.Equal(t, ErrAlreadyRegistered, err)
+	// Gauges implement prometheus.Counter, so the cached type must be checked
+	_, err = registry.GetOrCreateGauge("get_or_create_conflict", "test", "", "Cached gauge", nil)
+	assert.Nil(t, err)
+	_, err = registry.GetOrCreateCounter("get_or_create_conflict", "test", "", "Cached gauge", nil)
+	assert.Equal(t, ErrAlreadyRegistered, err)
+	_, err = registry.GetOrCreateGauge("", "test", "", "Missing name", nil)
+	assert.NotNil(t, err)
+}
+
+func TestMetricsServerHealth(t *testing.T) {
+	server := NewMetricsServer(nil, 0, "")
+	httpServer := httptest.NewServer(server.Mux)
+	defer httpServer.Close()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(httpServer.URL + path)
+		assert.Nil(t, err)
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	ready := false
+	server.SetReadinessCheck(func() bool { return ready })
+	status, body := get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, `{"status":"not ready"}`, body)
+	status, body = get("/healthz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"status":"ok"}`, body)
+
+	ready = true
+	status, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"status":"ok"}`, body)
+	status, _ = get("/metrics")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestHistogramSnapshot(t *testing.T) {
+	histogram, err := CreateHistogram("snapshot_test", "", "", "Test histogram", nil, []float64{1, 5})
+	assert.Nil(t, err)
+	histogram.Observe(0.5)
+	histogram.Observe(2)
+	histogram.Observe(10)
+
+	count, sum, buckets, err := HistogramSnapshot(histogram)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(3), count)
+	assert.Equal(t, 12.5, sum)
+	assert.Equal(t, []BucketCount{{UpperBound: 1, CumulativeCount: 1}, {UpperBound: 5, CumulativeCount: 2}}, buckets)
+
+	histogramVec, err := CreateHistogramVector("snapshot_vec_test", "", "", "Test histogram", nil, []string{"operation"}, []float64{1})
+	assert.Nil(t, err)
+	histogramVec.WithLabelValues("get").Observe(0.25)
+	count, sum, buckets, err = HistogramVecSnapshot(histogramVec, "get")
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), count)
+	assert.Equal(t, 0.25, sum)
+	assert.Equal(t, []BucketCount{{UpperBound: 1, CumulativeCount: 1}}, buckets)
+
+	_, _, _, err = HistogramVecSnapshot(histogramVec, "get", "extra")
+	assert.NotNil(t, err)
+}
+
+// Writes a self-signed certificate for 127.0.0.1 and its key to dir,
+// returning their paths and the certificate
+func writeSelfSignedCert(t *testing.T, dir string) (string, string, *x509.Certificate) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	assert.Nil(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Nil(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile, cert
+}
+
+func TestStartPrometheusMetricsServerTLS(t *testing.T) {
+	certFile, keyFile, cert := writeSelfSignedCert(t, t.TempDir())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	log, _ := logger.NewTestLogger("metrics")
+	go StartPrometheusMetricsServerTLS("tls", log, port, certFile, keyFile, &tls.Config{MinVersion: tls.VersionTLS12})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	var resp *http.Response
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("https://127.0.0.1:" + strconv.Itoa(port) + "/metrics"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if assert.Nil(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	// A missing certificate is returned and logged
+	log, capture := logger.NewTestLogger("metrics")
+	assert.NotNil(t, StartPrometheusMetricsServerTLS("tls", log, 0, "missing.pem", "missing.pem"))
+	if entries := capture.Entries(); assert.Equal(t, 1, len(entries)) {
+		assert.Contains(t, entries[0].Msg, "Error starting Prometheus metrics server")
+	}
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// NewHTTPMetricsMiddleware returns middleware recording the requests handled
+// by any http.Handler in request_duration_seconds (a histogram) and
+// requests_total (a counter), both labelled with method and status_code and
+// registered with registry, or the global registry if nil. Panics if the
+// metrics conflict with ones already registered.
+func NewHTTPMetricsMiddleware(namespace string, subsystem string, registry *MetricsRegistry) func(http.Handler) http.Handler {
+	if registry == nil {
+		registry = defaultRegistry
+	}
+	labelNames := []string{"method", "status_code"}
+	durations, err := registry.CreateHistogramVector("request_duration_seconds", namespace, subsystem, "Duration of HTTP requests in seconds.", nil, labelNames)
+	if err != nil {
+		panic(err)
+	}
+	requests, err := registry.CreateCounterVector("requests_total", namespace, subsystem, "Total number of HTTP requests handled.", nil, labelNames)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			statusCode := strconv.Itoa(recorder.status)
+			durations.WithLabelValues(r.Method, statusCode).Observe(time.Since(start).Seconds())
+			requests.WithLabelValues(r.Method, statusCode).Inc()
+		})
+	}
+}
+
+// Records the status code written to a ResponseWriter, 200 unless
+// WriteHeader is called
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if !recorder.wroteHeader {
+		recorder.status = status
+		recorder.wroteHeader = true
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(body []byte) (int, error) {
+	recorder.wroteHeader = true
+	return recorder.ResponseWriter.Write(body)
+}
+
+// Flush implements http.Flusher if the wrapped ResponseWriter does
+func (recorder *statusRecorder) Flush() {
+	if flusher, ok := recorder.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController access to the wrapped ResponseWriter
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
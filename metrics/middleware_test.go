@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil" // Prometheus testing helpers
+	"github.com/stretchr/testify/assert"                      // Assertion package
+)
+
+func TestHTTPMetricsMiddleware(t *testing.T) {
+	registry := NewMetricsRegistry()
+	middleware := NewHTTPMetricsMiddleware("test", "http", registry)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/created", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusInternalServerError) // ignored, as by net/http
+	})
+	handler := middleware(mux)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/ok", nil),
+		httptest.NewRequest("GET", "/ok", nil),
+		httptest.NewRequest("POST", "/created", nil),
+		httptest.NewRequest("GET", "/missing", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	requests, err := registry.CreateCounterVector("requests_total", "test", "http", "Total number of HTTP requests handled.", nil, []string{"method", "status_code"})
+	assert.Nil(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(requests.WithLabelValues("GET", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("POST", "201")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(requests.WithLabelValues("GET", "404")))
+
+	durations, err := registry.CreateHistogramVector("request_duration_seconds", "test", "http", "Duration of HTTP requests in seconds.", nil, []string{"method", "status_code"})
+	assert.Nil(t, err)
+	count, _ := histogramValues(t, durations.WithLabelValues("GET", "200"))
+	assert.Equal(t, uint64(2), count)
+	count, _ = histogramValues(t, durations.WithLabelValues("POST", "201"))
+	assert.Equal(t, uint64(1), count)
+
+	// Wrapping another handler reuses the registered metrics
+	assert.NotPanics(t, func() { NewHTTPMetricsMiddleware("test", "http", registry) })
+}
@@ -0,0 +1,301 @@
+// Package otelexporter bridges metrics from a Prometheus registry to an
+// OpenTelemetry MeterProvider, for services migrating from Prometheus to
+// OpenTelemetry
+package otelexporter
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+	dto "github.com/prometheus/client_model/go"      // Prometheus metric data model
+	"go.opentelemetry.io/otel"                       // OpenTelemetry global API
+	"go.opentelemetry.io/otel/attribute"             // OpenTelemetry attributes
+	"go.opentelemetry.io/otel/metric"                // OpenTelemetry metrics API
+)
+
+const (
+	DefaultCollectInterval   = 15 * time.Second
+	DefaultSecondsMultiplier = 1000 // seconds to milliseconds
+)
+
+// OTelBridge periodically gathers a Prometheus registry and records every
+// counter and gauge in an equivalent OpenTelemetry instrument of the same
+// name. Histograms are bridged as they are exposed to Prometheus, as counters
+// named <name>_bucket (with an "le" attribute for the cumulative count of
+// each bucket), <name>_count and <name>_sum, so the cost of a collection
+// depends on the number of buckets rather than observations. Counters record
+// the change since the previous collection. Summaries are not bridged.
+type OTelBridge struct {
+	// Time between collections, DefaultCollectInterval if not positive. Must
+	// be set before Start.
+	CollectInterval time.Duration
+
+	// Factor applied to the values of metrics measured in seconds (with
+	// "_seconds" in their name), 1000 to record milliseconds by default. 0
+	// records seconds. Must be set before the first collection.
+	SecondsMultiplier float64
+
+	gatherer prometheus.Gatherer
+	meter    metric.Meter
+
+	lock        sync.Mutex
+	instruments map[string]*instrument // by family name
+	previous    map[string]float64     // counter values and histogram bucket counts at the last collection, by series
+	done        chan struct{}
+	stopped     chan struct{}
+}
+
+// NewOTelBridge returns a bridge from registry, or the global registry if
+// nil, to meterProvider, or the global MeterProvider if nil. Returns an error
+// if the registry can't be gathered. Instruments are created as metrics are
+// first collected, call Start to begin collecting.
+func NewOTelBridge(registry *prometheus.Registry, meterProvider metric.MeterProvider) (*OTelBridge, error) {
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		gatherer = registry
+	}
+	if meterProvider == nil {
+		meterProvider = otel.GetMeterProvider()
+	}
+
+	bridge := &OTelBridge{
+		CollectInterval:   DefaultCollectInterval,
+		SecondsMultiplier: DefaultSecondsMultiplier,
+		gatherer:          gatherer,
+		meter:             meterProvider.Meter("github.com/bottlenose-inc/go-common-tools/metrics/otelexporter"),
+		instruments:       make(map[string]*instrument),
+		previous:          make(map[string]float64),
+	}
+	if _, err := gatherer.Gather(); err != nil {
+		return nil, err
+	}
+	return bridge, nil
+}
+
+// Start collects every CollectInterval in a background goroutine until ctx is
+// done or Stop is called. Must only be called once.
+func (bridge *OTelBridge) Start(ctx context.Context) {
+	interval := bridge.CollectInterval
+	if interval <= 0 {
+		interval = DefaultCollectInterval
+	}
+	bridge.done = make(chan struct{})
+	bridge.stopped = make(chan struct{})
+	go func() {
+		defer close(bridge.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				bridge.Collect(ctx)
+			case <-ctx.Done():
+				return
+			case <-bridge.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the collection loop started by Start, waiting for a collection
+// in progress to finish
+func (bridge *OTelBridge) Stop() {
+	if bridge.done == nil {
+		return
+	}
+	select {
+	case <-bridge.done:
+	default:
+		close(bridge.done)
+	}
+	<-bridge.stopped
+}
+
+// Collect gathers the registry once and records the metrics, as done every
+// CollectInterval after Start. Metrics whose instruments can't be created are
+// skipped, returning the first error.
+func (bridge *OTelBridge) Collect(ctx context.Context) error {
+	families, err := bridge.gatherer.Gather()
+	if err != nil {
+		return err
+	}
+
+	bridge.lock.Lock()
+	defer bridge.lock.Unlock()
+	var firstErr error
+	for _, family := range families {
+		instrument, err := bridge.instrument(family)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if instrument == nil {
+			continue
+		}
+		multiplier := bridge.multiplier(family.GetName())
+		for _, m := range family.GetMetric() {
+			bridge.record(ctx, family.GetName(), instrument, m, multiplier)
+		}
+	}
+	return firstErr
+}
+
+// OpenTelemetry instruments of a metric family, either counter, gauge or
+// the three histogram instruments
+type instrument struct {
+	counter metric.Float64Counter
+	gauge   metric.Float64Gauge
+
+	buckets metric.Float64Counter       // <name>_bucket
+	count   metric.Float64Counter       // <name>_count
+	sum     metric.Float64UpDownCounter // <name>_sum, which decreases with negative observations
+}
+
+// Returns the instrument for family, creating it if needed, or nil for
+// families that aren't bridged
+func (bridge *OTelBridge) instrument(family *dto.MetricFamily) (*instrument, error) {
+	name := family.GetName()
+	if existing, found := bridge.instruments[name]; found {
+		return existing, nil
+	}
+
+	unit := bridge.unit(name)
+	created := new(instrument)
+	var err error
+	switch family.GetType() {
+	case dto.MetricType_COUNTER:
+		created.counter, err = bridge.meter.Float64Counter(name, metric.WithDescription(family.GetHelp()), metric.WithUnit(unit))
+	case dto.MetricType_GAUGE, dto.MetricType_UNTYPED:
+		created.gauge, err = bridge.meter.Float64Gauge(name, metric.WithDescription(family.GetHelp()), metric.WithUnit(unit))
+	case dto.MetricType_HISTOGRAM:
+		created.buckets, err = bridge.meter.Float64Counter(name+"_bucket", metric.WithDescription(family.GetHelp()+" (cumulative count by upper bound)"))
+		if err == nil {
+			created.count, err = bridge.meter.Float64Counter(name+"_count", metric.WithDescription(family.GetHelp()+" (count)"))
+		}
+		if err == nil {
+			created.sum, err = bridge.meter.Float64UpDownCounter(name+"_sum", metric.WithDescription(family.GetHelp()+" (sum)"), metric.WithUnit(unit))
+		}
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	bridge.instruments[name] = created
+	return created, nil
+}
+
+// Returns the factor applied to values of the named metric
+func (bridge *OTelBridge) multiplier(name string) float64 {
+	if bridge.SecondsMultiplier != 0 && strings.Contains(name, "_seconds") {
+		return bridge.SecondsMultiplier
+	}
+	return 1
+}
+
+// Returns the unit of the named metric after applying its multiplier, if known
+func (bridge *OTelBridge) unit(name string) string {
+	if !strings.Contains(name, "_seconds") {
+		return ""
+	}
+	switch bridge.SecondsMultiplier {
+	case 0, 1:
+		return "s"
+	case 1000:
+		return "ms"
+	case 1000000:
+		return "us"
+	case 1000000000:
+		return "ns"
+	default:
+		return ""
+	}
+}
+
+// Records the change in a series since the last collection
+func (bridge *OTelBridge) record(ctx context.Context, name string, instrument *instrument, m *dto.Metric, multiplier float64) {
+	keyValues, series := labelAttributes(name, m.GetLabel())
+	options := metric.WithAttributeSet(attribute.NewSet(keyValues...))
+
+	switch {
+	case instrument.counter != nil:
+		value := m.GetCounter().GetValue()
+		instrument.counter.Add(ctx, bridge.delta(series, value)*multiplier, options)
+	case instrument.gauge != nil:
+		value := m.GetGauge().GetValue()
+		if m.Untyped != nil {
+			value = m.GetUntyped().GetValue()
+		}
+		instrument.gauge.Record(ctx, value*multiplier, options)
+	case instrument.buckets != nil:
+		histogram := m.GetHistogram()
+		count := float64(histogram.GetSampleCount())
+		reset := count < bridge.previous[series+"\xff_count"]
+		instrument.count.Add(ctx, bridge.delta(series+"\xff_count", count), options)
+
+		sum := histogram.GetSampleSum()
+		previousSum := bridge.previous[series+"\xff_sum"]
+		bridge.previous[series+"\xff_sum"] = sum
+		if !reset {
+			sum -= previousSum
+		}
+		instrument.sum.Add(ctx, sum*multiplier, options)
+
+		hasInf := false
+		for _, bucket := range histogram.GetBucket() {
+			hasInf = math.IsInf(bucket.GetUpperBound(), 1)
+			bridge.recordBucket(ctx, instrument, series, keyValues, bucket.GetUpperBound(), bucket.GetCumulativeCount(), multiplier)
+		}
+		// The +Inf bucket is implicit in client_golang histograms
+		if !hasInf {
+			bridge.recordBucket(ctx, instrument, series, keyValues, math.Inf(1), histogram.GetSampleCount(), multiplier)
+		}
+	}
+}
+
+// Records the change in the cumulative count of a histogram bucket
+func (bridge *OTelBridge) recordBucket(ctx context.Context, instrument *instrument, series string, keyValues []attribute.KeyValue, bound float64, cumulativeCount uint64, multiplier float64) {
+	le := attribute.String("le", bucketKey(bound*multiplier))
+	options := metric.WithAttributeSet(attribute.NewSet(append(keyValues[:len(keyValues):len(keyValues)], le)...))
+	added := bridge.delta(series+"\xff"+bucketKey(bound), float64(cumulativeCount))
+	instrument.buckets.Add(ctx, added, options)
+}
+
+// Returns the increase of a cumulative value since the last collection,
+// treating a decrease as a reset
+func (bridge *OTelBridge) delta(series string, value float64) float64 {
+	previous := bridge.previous[series]
+	bridge.previous[series] = value
+	if value < previous {
+		return value
+	}
+	return value - previous
+}
+
+func bucketKey(bound float64) string {
+	if math.IsInf(bound, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(bound, 'g', -1, 64)
+}
+
+// Returns the attributes for labels and a key identifying the series
+func labelAttributes(name string, labels []*dto.LabelPair) ([]attribute.KeyValue, string) {
+	keyValues := make([]attribute.KeyValue, 0, len(labels))
+	pairs := make([]string, 0, len(labels))
+	for _, label := range labels {
+		keyValues = append(keyValues, attribute.String(label.GetName(), label.GetValue()))
+		pairs = append(pairs, label.GetName()+"="+label.GetValue())
+	}
+	sort.Strings(pairs)
+	return keyValues, name + "{" + strings.Join(pairs, "\xff") + "}"
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"      // Official Prometheus golang library
+	"github.com/prometheus/client_golang/prometheus/push" // Prometheus push gateway client
+)
+
+// PushMetrics replaces the metrics of job on the push gateway at gatewayURL
+// with every metric in registry, or the global registry if nil. Meant for
+// batch jobs that exit before they can be scraped.
+func PushMetrics(job string, gatewayURL string, registry *prometheus.Registry) error {
+	return PushMetricsWithGrouping(job, gatewayURL, nil, registry)
+}
+
+// PushMetricsWithGrouping is like PushMetrics, grouping the pushed metrics by
+// groupLabels in addition to job
+func PushMetricsWithGrouping(job string, gatewayURL string, groupLabels map[string]string, registry *prometheus.Registry) error {
+	var gatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if registry != nil {
+		gatherer = registry
+	}
+
+	pusher := push.New(gatewayURL, job).Gatherer(gatherer)
+	for name, value := range groupLabels {
+		pusher.Grouping(name, value)
+	}
+	if err := pusher.Push(); err != nil {
+		return fmt.Errorf("Error pushing metrics for job %s to %s: %s", job, gatewayURL, err.Error())
+	}
+	return nil
+}
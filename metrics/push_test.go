@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bottlenose-inc/go-common-tools/testhttp" // go-common-tools HTTP mocking package
+	"github.com/stretchr/testify/assert"                 // Assertion package
+)
+
+func TestPushMetrics(t *testing.T) {
+	mock := testhttp.InitMockHTTP()
+	defer mock.Close()
+	mock.AddTestData("/metrics/job/batch", http.StatusAccepted, nil)
+	mock.AddTestData("/metrics/job/batch/shard/1", http.StatusAccepted, nil)
+
+	registry := NewMetricsRegistry()
+	counter, err := registry.CreateCounter("pushed_total", "test", "", "Pushed counter", nil)
+	assert.Nil(t, err)
+	counter.Inc()
+
+	assert.Nil(t, PushMetrics("batch", mock.Server.URL, registry.Registry))
+	assert.Equal(t, 1, mock.RequestCount("PUT", "/metrics/job/batch"))
+
+	assert.Nil(t, PushMetricsWithGrouping("batch", mock.Server.URL, map[string]string{"shard": "1"}, registry.Registry))
+	assert.Equal(t, 1, mock.RequestCount("PUT", "/metrics/job/batch/shard/1"))
+
+	err = PushMetrics("unknown", mock.Server.URL, registry.Registry)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "Error pushing metrics for job unknown")
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// MetricsRegistry creates metrics registered with a Prometheus registry,
+// letting tests use an isolated registry instead of the global one. The
+// package-level Create* functions use a MetricsRegistry for the global
+// registry. Safe for concurrent use.
+type MetricsRegistry struct {
+	Registry *prometheus.Registry // nil for the global registry
+
+	lock        sync.Mutex                   // serializes registrations by the Create* methods
+	types       map[string]string            // types of metrics registered by the Create* methods, by fully-qualified name
+	constLabels map[string]map[string]string // const labels of metrics registered by the Create* methods, by fully-qualified name
+	collectors  []prometheus.Collector       // registered by the Create* methods, see Clear
+
+	cacheLock sync.RWMutex
+	cache     map[string]cachedMetric // metrics returned by the GetOrCreate* methods, by fully-qualified name
+}
+
+// Registry of the package-level Create* functions
+var defaultRegistry = newMetricsRegistry(nil)
+
+// NewMetricsRegistry returns a MetricsRegistry with a new, empty registry
+func NewMetricsRegistry() *MetricsRegistry {
+	return newMetricsRegistry(prometheus.NewRegistry())
+}
+
+func newMetricsRegistry(registry *prometheus.Registry) *MetricsRegistry {
+	return &MetricsRegistry{
+		Registry:    registry,
+		types:       make(map[string]string),
+		constLabels: make(map[string]map[string]string),
+		cache:       make(map[string]cachedMetric),
+	}
+}
+
+// Returns Registry, or the global registry if nil
+func (metricsRegistry *MetricsRegistry) registerer() prometheus.Registerer {
+	if metricsRegistry.Registry == nil {
+		return prometheus.DefaultRegisterer
+	}
+	return metricsRegistry.Registry
+}
+
+// Registers a metric created by a Create* method. If an identical metric is
+// already registered it is returned instead, unless it was created as a
+// different metricType.
+func (metricsRegistry *MetricsRegistry) register(metricType string, collector prometheus.Collector, name string, namespace string, subsystem string, labels map[string]string) (prometheus.Collector, error) {
+	registerer := metricsRegistry.registerer()
+	fqName := prometheus.BuildFQName(namespace, subsystem, name)
+
+	metricsRegistry.lock.Lock()
+	defer metricsRegistry.lock.Unlock()
+	if err := registerer.Register(collector); err != nil {
+		existing, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			return nil, err
+		}
+		if registeredType, found := metricsRegistry.types[fqName]; found && registeredType != metricType {
+			return nil, ErrAlreadyRegistered
+		}
+		return existing.ExistingCollector, nil
+	}
+	metricsRegistry.types[fqName] = metricType
+	metricsRegistry.constLabels[fqName] = labels
+	metricsRegistry.collectors = append(metricsRegistry.collectors, collector)
+	recordCreation(metricType, name, namespace, subsystem, labels)
+	return collector, nil
+}
+
+// Unregisters a collector newly registered by register, forgetting its type
+func (metricsRegistry *MetricsRegistry) unregister(collector prometheus.Collector, fqName string) {
+	metricsRegistry.lock.Lock()
+	defer metricsRegistry.lock.Unlock()
+	metricsRegistry.registerer().Unregister(collector)
+	delete(metricsRegistry.types, fqName)
+	delete(metricsRegistry.constLabels, fqName)
+	for i, registered := range metricsRegistry.collectors {
+		if registered == collector {
+			metricsRegistry.collectors = append(metricsRegistry.collectors[:i], metricsRegistry.collectors[i+1:]...)
+			break
+		}
+	}
+}
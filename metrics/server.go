@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bottlenose-inc/go-common-tools/logger" // go-common-tools logger package
+	"github.com/prometheus/client_golang/prometheus"   // Official Prometheus golang library
+)
+
+// MetricsServer is a Prometheus metrics HTTP server that can be started in
+// the background and shut down gracefully, unlike StartPrometheusMetricsServer
+type MetricsServer struct {
+	Mux      *http.ServeMux
+	Server   *http.Server
+	logger   *logger.Logger
+	listener net.Listener
+	stopped  chan struct{} // closed once Serve returns
+	err      error         // error returned by Serve, set before stopped is closed
+
+	readyLock sync.RWMutex
+	ready     func() bool // see SetReadinessCheck
+}
+
+// NewMetricsServer returns a MetricsServer serving metrics on the given port
+// and path ("/metrics" if empty), along with /healthz and /readyz for health
+// probes. Port 0 picks a free port when started.
+func NewMetricsServer(logger *logger.Logger, port int, path string) *MetricsServer {
+	if path == "" {
+		path = "/metrics"
+	}
+	server := new(MetricsServer)
+	server.logger = logger
+	server.Mux = http.NewServeMux()
+	server.Mux.Handle(path, prometheus.Handler())
+	server.handleHealth()
+	server.Server = &http.Server{Addr: ":" + strconv.Itoa(port), Handler: server.Mux}
+	return server
+}
+
+// Start binds the server's port and serves requests in a background goroutine.
+// Errors binding the port are returned, later serving errors are logged.
+func (server *MetricsServer) Start() error {
+	listener, err := net.Listen("tcp", server.Server.Addr)
+	if err != nil {
+		server.logger.Error("Error starting Prometheus metrics server: " + err.Error())
+		return err
+	}
+	server.listener = listener
+	server.stopped = make(chan struct{})
+	go func() {
+		defer close(server.stopped)
+		if err := server.Server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			server.logger.Error("Error serving Prometheus metrics: " + err.Error())
+			server.err = err
+		}
+	}()
+	return nil
+}
+
+// Addr returns the address the server is listening on, or an empty string if
+// it has not been started
+func (server *MetricsServer) Addr() string {
+	if server.listener == nil {
+		return ""
+	}
+	return server.listener.Addr().String()
+}
+
+// Shutdown gracefully stops the server, waiting for in-flight requests until
+// ctx is done
+func (server *MetricsServer) Shutdown(ctx context.Context) error {
+	return server.Server.Shutdown(ctx)
+}
+
+// Time allowed for in-flight requests when the context of
+// StartPrometheusMetricsServerAsync is done
+const asyncShutdownTimeout = 5 * time.Second
+
+var (
+	runningLock    sync.Mutex
+	runningServers = make(map[string]PrometheusId) // started by StartPrometheusMetricsServerAsync, by ID
+)
+
+// StartPrometheusMetricsServerAsync serves the global registry on /metrics in
+// a background goroutine until ctx is done, then shuts the server down
+// gracefully. The returned channel receives the error that stopped the server,
+// including errors binding port, and is closed once the server has stopped.
+// The PrometheusId describing the server is nil if port could not be bound.
+func StartPrometheusMetricsServerAsync(ctx context.Context, name string, logger *logger.Logger, port int) (*PrometheusId, <-chan error) {
+	errs := make(chan error, 1)
+	server := NewMetricsServer(logger, port, "")
+	if err := server.Start(); err != nil {
+		errs <- err
+		close(errs)
+		return nil, errs
+	}
+
+	addr := server.listener.Addr().(*net.TCPAddr)
+	id := PrometheusId{
+		Name:    name,
+		Address: addr.IP.String(),
+		Port:    addr.Port,
+		ID:      fmt.Sprintf("%s:%d", name, addr.Port),
+	}
+	runningLock.Lock()
+	runningServers[id.ID] = id
+	runningLock.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-server.stopped:
+			return // Serve failed, nothing to shut down
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), asyncShutdownTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		<-server.stopped
+		runningLock.Lock()
+		delete(runningServers, id.ID)
+		runningLock.Unlock()
+		if server.err != nil {
+			errs <- server.err
+		}
+		close(errs)
+	}()
+	return &id, errs
+}
+
+// RunningPrometheusServers describes the servers started by
+// StartPrometheusMetricsServerAsync that have not stopped, sorted by ID
+func RunningPrometheusServers() []PrometheusId {
+	runningLock.Lock()
+	defer runningLock.Unlock()
+	ids := make([]PrometheusId, 0, len(runningServers))
+	for _, id := range runningServers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i].ID < ids[j].ID })
+	return ids
+}
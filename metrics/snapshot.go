@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+	dto "github.com/prometheus/client_model/go"      // Prometheus metric data model
+)
+
+// BucketCount is the number of observations in a histogram less than or
+// equal to UpperBound, see HistogramSnapshot
+type BucketCount struct {
+	UpperBound      float64
+	CumulativeCount uint64
+}
+
+// HistogramSnapshot returns the current sample count, sample sum and buckets
+// of histogram, for asserting on in tests
+func HistogramSnapshot(histogram prometheus.Histogram) (count uint64, sum float64, buckets []BucketCount, err error) {
+	var metric dto.Metric
+	if err = histogram.Write(&metric); err != nil {
+		return 0, 0, nil, err
+	}
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		buckets = append(buckets, BucketCount{UpperBound: bucket.GetUpperBound(), CumulativeCount: bucket.GetCumulativeCount()})
+	}
+	return metric.GetHistogram().GetSampleCount(), metric.GetHistogram().GetSampleSum(), buckets, nil
+}
+
+// HistogramVecSnapshot returns the HistogramSnapshot of the histogram of
+// histogramVec with the given label values
+func HistogramVecSnapshot(histogramVec *prometheus.HistogramVec, labelValues ...string) (count uint64, sum float64, buckets []BucketCount, err error) {
+	observer, err := histogramVec.GetMetricWithLabelValues(labelValues...)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return HistogramSnapshot(observer.(prometheus.Histogram))
+}
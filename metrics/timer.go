@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// Timer observes the seconds elapsed since it was created in a histogram:
+//
+//	defer metrics.NewTimer(histogram).DeferObserveDuration()()
+type Timer struct {
+	observer prometheus.Observer
+	start    time.Time
+}
+
+// NewTimer returns a Timer started now observing into histogram
+func NewTimer(histogram prometheus.Histogram) *Timer {
+	return &Timer{observer: histogram, start: time.Now()}
+}
+
+// NewTimerVec returns a Timer started now observing into the histogram of
+// histogramVec with the given label values. Panics if the number of label
+// values doesn't match the HistogramVec's label names.
+func NewTimerVec(histogramVec *prometheus.HistogramVec, labelValues ...string) *Timer {
+	return &Timer{observer: histogramVec.WithLabelValues(labelValues...), start: time.Now()}
+}
+
+// ObserveDuration observes the seconds elapsed since the Timer was created,
+// returning the elapsed time
+func (timer *Timer) ObserveDuration() time.Duration {
+	elapsed := time.Since(timer.start)
+	timer.observer.Observe(elapsed.Seconds())
+	return elapsed
+}
+
+// DeferObserveDuration returns a function calling ObserveDuration, for use
+// with defer
+func (timer *Timer) DeferObserveDuration() func() {
+	return func() {
+		timer.ObserveDuration()
+	}
+}
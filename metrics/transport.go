@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// Status code label of requests that failed without a response
+const transportErrorStatus = "error"
+
+// Transport recording the requests made through it, see
+// NewInstrumentedTransport
+type instrumentedTransport struct {
+	base       http.RoundTripper
+	histVec    *prometheus.HistogramVec
+	counterVec *prometheus.CounterVec
+}
+
+// NewInstrumentedTransport wraps base, or http.DefaultTransport if nil, to
+// observe the duration in seconds of every outgoing request in histVec and
+// count it in counterVec, either of which may be nil. Both must have the
+// labels host and status_code, which is "error" for requests that failed
+// without a response. Observations with mismatched labels are skipped.
+func NewInstrumentedTransport(base http.RoundTripper, histVec *prometheus.HistogramVec, counterVec *prometheus.CounterVec) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &instrumentedTransport{base: base, histVec: histVec, counterVec: counterVec}
+}
+
+func (transport *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := transport.base.RoundTrip(req)
+	labels := prometheus.Labels{"host": req.URL.Host, "status_code": transportErrorStatus}
+	if err == nil {
+		labels["status_code"] = strconv.Itoa(resp.StatusCode)
+	}
+
+	if transport.histVec != nil {
+		if histogram, labelErr := transport.histVec.GetMetricWith(labels); labelErr == nil {
+			histogram.Observe(time.Since(start).Seconds())
+		}
+	}
+	if transport.counterVec != nil {
+		if counter, labelErr := transport.counterVec.GetMetricWith(labels); labelErr == nil {
+			counter.Inc()
+		}
+	}
+	return resp, err
+}
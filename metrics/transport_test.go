@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bottlenose-inc/go-common-tools/testhttp"      // go-common-tools HTTP mocking package
+	"github.com/prometheus/client_golang/prometheus/testutil" // Prometheus testing helpers
+	"github.com/stretchr/testify/assert"                      // Assertion package
+)
+
+func TestInstrumentedTransport(t *testing.T) {
+	mock := testhttp.InitMockHTTP()
+	defer mock.Close()
+	mock.AddTestData("http://example.com/users", http.StatusOK, []byte("[]"))
+
+	registry := NewMetricsRegistry()
+	labelNames := []string{"host", "status_code"}
+	histVec, err := registry.CreateHistogramVector("client_duration_seconds", "test", "", "Outgoing request durations", nil, labelNames)
+	assert.Nil(t, err)
+	counterVec, err := registry.CreateCounterVector("client_requests_total", "test", "", "Outgoing requests", nil, labelNames)
+	assert.Nil(t, err)
+	client := &http.Client{Transport: NewInstrumentedTransport(mock.Client.Transport, histVec, counterVec)}
+
+	for _, testUrl := range []string{"http://example.com/users", "http://example.com/users", "http://example.com/missing"} {
+		resp, err := client.Get(testUrl)
+		assert.Nil(t, err)
+		resp.Body.Close()
+	}
+	mock.PartitionNetwork(3, 4)
+	_, err = client.Post("http://example.com/users", "application/json", nil)
+	assert.NotNil(t, err)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(counterVec.WithLabelValues("example.com", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counterVec.WithLabelValues("example.com", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(counterVec.WithLabelValues("example.com", "error")))
+	count, _ := histogramValues(t, histVec.WithLabelValues("example.com", "200"))
+	assert.Equal(t, uint64(2), count)
+	count, _ = histogramValues(t, histVec.WithLabelValues("example.com", "error"))
+	assert.Equal(t, uint64(1), count)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus" // Official Prometheus golang library
+)
+
+// ValidateMetricName returns an error identifying the first character of name
+// not allowed in Prometheus metric names, which must match
+// [a-zA-Z_:][a-zA-Z0-9_:]*
+func ValidateMetricName(name string) error {
+	return validateName("metric", name, true)
+}
+
+// ValidateLabelName returns an error identifying the first character of name
+// not allowed in Prometheus label names, which must match [a-zA-Z_][a-zA-Z0-9_]*
+// and not start with the reserved prefix "__"
+func ValidateLabelName(name string) error {
+	if strings.HasPrefix(name, "__") {
+		return fmt.Errorf("Invalid label name %q: names starting with \"__\" are reserved", name)
+	}
+	return validateName("label", name, false)
+}
+
+func validateName(kind string, name string, allowColon bool) error {
+	if name == "" {
+		return errors.New("Invalid " + kind + " name: must not be empty")
+	}
+	for i, char := range name {
+		valid := char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
+			(allowColon && char == ':') || (i > 0 && char >= '0' && char <= '9')
+		if !valid {
+			return fmt.Errorf("Invalid %s name %q: character %q at position %d is not allowed", kind, name, char, i)
+		}
+	}
+	return nil
+}
+
+// Validates the fully-qualified name and label names of a metric created by
+// a Create* method
+func validateNames(namespace string, subsystem string, name string, constLabels map[string]string, labelNames []string) error {
+	if err := ValidateMetricName(prometheus.BuildFQName(namespace, subsystem, name)); err != nil {
+		return err
+	}
+	for labelName := range constLabels {
+		if err := ValidateLabelName(labelName); err != nil {
+			return err
+		}
+	}
+	for _, labelName := range labelNames {
+		if err := ValidateLabelName(labelName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package observability
+
+import (
+	"context"
+
+	"github.com/bottlenose-inc/go-common-tools/logger"                // go-common-tools logger package
+	"github.com/bottlenose-inc/go-common-tools/metrics"               // go-common-tools metrics package
+	"go.opentelemetry.io/otel"                                        // OpenTelemetry global API
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp" // OTLP HTTP trace exporter
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"                     // OpenTelemetry tracing SDK
+)
+
+type LoggerConfig struct {
+	Name  string
+	Level string // see logger.SetLogLevel, defaults to the LOG_LEVEL environment variable
+	Path  string // log file path, logs to stdout if empty
+}
+
+type MetricsConfig struct {
+	Port      int
+	Path      string // defaults to "/metrics"
+	Namespace string // namespace of the metrics created by Init, such as <Namespace>_log_entries_total
+}
+
+type TracingConfig struct {
+	Endpoint string // OTLP HTTP exporter endpoint (host:port), tracing is not exported if empty
+	Insecure bool   // use HTTP rather than HTTPS to reach Endpoint
+}
+
+type Config struct {
+	Logger  LoggerConfig
+	Metrics MetricsConfig
+	Tracing TracingConfig
+}
+
+// Bundle holds the initialized logger, metrics server and tracer provider
+type Bundle struct {
+	Config         Config
+	Logger         *logger.Logger
+	MetricsServer  *metrics.MetricsServer
+	TracerProvider *sdktrace.TracerProvider
+}
+
+// Init creates a logger counting its entries in the global registry (see
+// logger.EnableMetrics), starts a metrics server and registers a global
+// tracer provider according to cfg. Anything already initialized is shut
+// down again if a later step fails.
+func Init(cfg Config) (*Bundle, error) {
+	bundle := &Bundle{Config: cfg}
+
+	// Logger
+	var args []string
+	if cfg.Logger.Path != "" {
+		args = append(args, cfg.Logger.Path)
+	}
+	log, err := logger.NewLogger(cfg.Logger.Name, args...)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Logger.Level != "" {
+		log.SetLogLevel(cfg.Logger.Level)
+	}
+	bundle.Logger = log
+
+	// Metrics
+	if err := log.EnableMetrics(cfg.Metrics.Namespace, nil); err != nil {
+		log.Close()
+		return nil, err
+	}
+	bundle.MetricsServer = metrics.NewMetricsServer(log, cfg.Metrics.Port, cfg.Metrics.Path)
+	if err := bundle.MetricsServer.Start(); err != nil {
+		log.DisableMetrics()
+		log.Close()
+		return nil, err
+	}
+
+	// Tracing
+	var opts []sdktrace.TracerProviderOption
+	if cfg.Tracing.Endpoint != "" {
+		exporterOpts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Tracing.Endpoint)}
+		if cfg.Tracing.Insecure {
+			exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), exporterOpts...)
+		if err != nil {
+			log.Error("Error creating OTLP trace exporter: " + err.Error())
+			bundle.MetricsServer.Shutdown(context.Background())
+			log.DisableMetrics()
+			log.Close()
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+	bundle.TracerProvider = sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(bundle.TracerProvider)
+
+	return bundle, nil
+}
+
+// Close flushes and stops the tracer provider, then the metrics server, then
+// unregisters the logger's metrics and closes it. The first error encountered
+// is returned.
+func (bundle *Bundle) Close(ctx context.Context) error {
+	var firstErr error
+	if err := bundle.TracerProvider.Shutdown(ctx); err != nil {
+		bundle.Logger.Error("Error shutting down tracer provider: " + err.Error())
+		firstErr = err
+	}
+	if err := bundle.MetricsServer.Shutdown(ctx); err != nil {
+		bundle.Logger.Error("Error shutting down metrics server: " + err.Error())
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	bundle.Logger.DisableMetrics()
+	flushErr, closeErr := bundle.Logger.Close()
+	for _, err := range []error{flushErr, closeErr} {
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,41 @@
+package observability
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestInitAndClose(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "service.log")
+	bundle, err := Init(Config{
+		Logger:  LoggerConfig{Name: "test", Level: "info", Path: logPath},
+		Metrics: MetricsConfig{Port: 0, Namespace: "test"},
+	})
+	assert.Nil(t, err)
+
+	bundle.Logger.Info("initialized")
+	resp, err := http.Get("http://" + bundle.MetricsServer.Addr() + "/metrics")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.True(t, strings.Contains(string(body), `test_log_entries_total{level="info"}`))
+
+	assert.NotNil(t, bundle.TracerProvider.Tracer("test"))
+	assert.Nil(t, bundle.Close(context.Background()))
+
+	raw, err := ioutil.ReadFile(logPath)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(raw), `"msg":"initialized"`))
+
+	// Close unregisters the metrics, so services can be initialized again
+	bundle, err = Init(Config{Logger: LoggerConfig{Name: "test", Path: logPath}, Metrics: MetricsConfig{Namespace: "test"}})
+	assert.Nil(t, err)
+	assert.Nil(t, bundle.Close(context.Background()))
+}
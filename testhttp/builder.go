@@ -0,0 +1,103 @@
+package testhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ResponseBuilder builds the responses returned for a method and URL, see
+// MockHTTP.Stub
+type ResponseBuilder struct {
+	mock      *MockHTTP
+	key       RequestKey
+	responses []TestHTTPResponse
+	err       error
+}
+
+// Stub returns a ResponseBuilder for requests to testUrl with the given
+// method. Nothing is served until Register is called, after which stubbed
+// responses take priority over test data added with AddTestData.
+//
+//	mock.Stub("GET", "http://example.com/users/1").
+//		Status(http.StatusServiceUnavailable).
+//		Then().JSONBody(user).Header("X-Cache", "miss").
+//		Register()
+func (mock *MockHTTP) Stub(method, testUrl string) *ResponseBuilder {
+	builder := &ResponseBuilder{mock: mock, key: RequestKey{method, testUrl}}
+	return builder.Then()
+}
+
+// Returns the response being built
+func (builder *ResponseBuilder) current() *TestHTTPResponse {
+	return &builder.responses[len(builder.responses)-1]
+}
+
+// Status sets the status code of the response, 200 by default
+func (builder *ResponseBuilder) Status(code int) *ResponseBuilder {
+	builder.current().Status = code
+	return builder
+}
+
+// Body sets the body of the response
+func (builder *ResponseBuilder) Body(body []byte) *ResponseBuilder {
+	builder.current().Body = body
+	return builder
+}
+
+// JSONBody sets the body of the response to v marshalled to JSON and its
+// Content-Type to application/json. Marshalling errors are returned by Register.
+func (builder *ResponseBuilder) JSONBody(v interface{}) *ResponseBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		if builder.err == nil {
+			builder.err = err
+		}
+		return builder
+	}
+	return builder.Body(body).Header("Content-Type", "application/json")
+}
+
+// Header sets a header of the response
+func (builder *ResponseBuilder) Header(name, value string) *ResponseBuilder {
+	response := builder.current()
+	if response.Headers == nil {
+		response.Headers = make(map[string]string)
+	}
+	response.Headers[name] = value
+	return builder
+}
+
+// Cookie adds a Set-Cookie header to the response
+func (builder *ResponseBuilder) Cookie(cookie *http.Cookie) *ResponseBuilder {
+	response := builder.current()
+	response.Cookies = append(response.Cookies, cookie)
+	return builder
+}
+
+// Delay makes the mock server wait before sending the response
+func (builder *ResponseBuilder) Delay(delay time.Duration) *ResponseBuilder {
+	builder.current().Delay = delay
+	return builder
+}
+
+// Then starts the next response in the sequence. Successive requests get
+// successive responses, the last one is repeated once all have been served.
+func (builder *ResponseBuilder) Then() *ResponseBuilder {
+	builder.responses = append(builder.responses, TestHTTPResponse{Status: http.StatusOK})
+	return builder
+}
+
+// Register serves the built responses, replacing any previously stubbed for
+// the same method and URL. Returns the first error encountered while building.
+func (builder *ResponseBuilder) Register() error {
+	if builder.err != nil {
+		return builder.err
+	}
+	responses := append([]TestHTTPResponse(nil), builder.responses...)
+
+	builder.mock.lock.Lock()
+	defer builder.mock.lock.Unlock()
+	builder.mock.stubs[builder.key] = &stub{responses: responses}
+	return nil
+}
@@ -0,0 +1,48 @@
+package testhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestStub(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/users/1", http.StatusOK, []byte("static"))
+	err := mock.Stub("GET", "http://example.com/users/1").
+		Status(http.StatusServiceUnavailable).Delay(50*time.Millisecond).
+		Then().JSONBody(map[string]string{"name": "test"}).Header("X-Cache", "miss").Cookie(&http.Cookie{Name: "session", Value: "abc"}).
+		Register()
+	assert.Nil(t, err)
+
+	start := time.Now()
+	status, _ := get(t, mock, "http://example.com/users/1")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		resp, err := mock.Client.Get("http://example.com/users/1")
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `{"name":"test"}`, string(body))
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "miss", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "abc", resp.Cookies()[0].Value)
+	}
+
+	// Other methods are not stubbed
+	resp, err := mock.Client.Post("http://example.com/users/1", "text/plain", nil)
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "static", string(body))
+
+	assert.NotNil(t, mock.Stub("GET", "http://example.com/invalid").JSONBody(func() {}).Register())
+}
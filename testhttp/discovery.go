@@ -0,0 +1,73 @@
+package testhttp
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// ServiceDiscoveryMock maps service names to MockHTTP servers, standing in
+// for service discovery in tests of service-to-service calls
+type ServiceDiscoveryMock struct {
+	lock     sync.RWMutex
+	services map[string]*MockHTTP
+}
+
+func NewServiceDiscoveryMock() *ServiceDiscoveryMock {
+	return &ServiceDiscoveryMock{services: make(map[string]*MockHTTP)}
+}
+
+// Register resolves serviceName to the URL of mock
+func (sdm *ServiceDiscoveryMock) Register(serviceName string, mock *MockHTTP) {
+	sdm.lock.Lock()
+	defer sdm.lock.Unlock()
+	sdm.services[serviceName] = mock
+}
+
+// Lookup returns the URL of the mock registered for serviceName
+func (sdm *ServiceDiscoveryMock) Lookup(serviceName string) (string, error) {
+	mock, err := sdm.lookup(serviceName)
+	if err != nil {
+		return "", err
+	}
+	return mock.Server.URL, nil
+}
+
+func (sdm *ServiceDiscoveryMock) lookup(serviceName string) (*MockHTTP, error) {
+	sdm.lock.RLock()
+	defer sdm.lock.RUnlock()
+	mock, found := sdm.services[serviceName]
+	if !found {
+		return nil, fmt.Errorf("Service not registered: %s", serviceName)
+	}
+	return mock, nil
+}
+
+// AsRoundTripper returns a transport sending requests for registered service
+// names to their mock, e.g. http://my-service/path to
+// http://127.0.0.1:<port>/path. Requests for unregistered hosts fail.
+func (sdm *ServiceDiscoveryMock) AsRoundTripper() http.RoundTripper {
+	return serviceDiscoveryTransport{sdm}
+}
+
+type serviceDiscoveryTransport struct {
+	sdm *ServiceDiscoveryMock
+}
+
+func (transport serviceDiscoveryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mock, err := transport.sdm.lookup(req.URL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	serverUrl, err := url.Parse(mock.Server.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved := req.Clone(req.Context())
+	resolved.URL.Scheme = serverUrl.Scheme
+	resolved.URL.Host = serverUrl.Host
+	resolved.Host = serverUrl.Host
+	return mock.Server.Client().Transport.RoundTrip(resolved)
+}
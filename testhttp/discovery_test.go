@@ -0,0 +1,35 @@
+package testhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestServiceDiscoveryMock(t *testing.T) {
+	users := InitMockHTTP()
+	defer users.Close()
+	users.AddTestData("/users/1", http.StatusOK, []byte(`{"id":1}`))
+
+	sdm := NewServiceDiscoveryMock()
+	sdm.Register("user-service", users)
+
+	resolved, err := sdm.Lookup("user-service")
+	assert.Nil(t, err)
+	assert.Equal(t, users.Server.URL, resolved)
+	_, err = sdm.Lookup("unknown-service")
+	assert.NotNil(t, err)
+
+	client := http.Client{Transport: sdm.AsRoundTripper()}
+	resp, err := client.Get("http://user-service/users/1")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"id":1}`, string(body))
+
+	_, err = client.Get("http://unknown-service/users/1")
+	assert.NotNil(t, err)
+}
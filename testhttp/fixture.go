@@ -0,0 +1,216 @@
+package testhttp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// On-disk format of a MockHTTP fixture
+type fixture struct {
+	Responses map[string]fixtureResponse `json:"responses"`
+	Sequences []fixtureSequence          `json:"sequences,omitempty"`
+}
+
+// A TestHTTPResponse in a fixture. Bodies are stored as text when they are
+// valid UTF-8 so fixtures stay editable, otherwise base64 encoded. Delays are
+// durations such as "250ms" and cookies Set-Cookie header values.
+type fixtureResponse struct {
+	Status     int               `json:"status"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Cookies    []string          `json:"cookies,omitempty"`
+	Delay      string            `json:"delay,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	BodyBase64 string            `json:"bodyBase64,omitempty"`
+}
+
+// Responses served in order for a method (any if empty) and URL, as
+// registered with AddTestDataSequence, AddTestDataForMethod or Stub
+type fixtureSequence struct {
+	Method    string            `json:"method,omitempty"`
+	URL       string            `json:"url"`
+	Responses []fixtureResponse `json:"responses"`
+	AfterLast *fixtureResponse  `json:"afterLast,omitempty"`
+}
+
+func newFixtureResponse(response TestHTTPResponse) fixtureResponse {
+	fixtureResp := fixtureResponse{Status: response.Status, Headers: response.Headers}
+	for _, cookie := range response.Cookies {
+		fixtureResp.Cookies = append(fixtureResp.Cookies, cookie.String())
+	}
+	if response.Delay > 0 {
+		fixtureResp.Delay = response.Delay.String()
+	}
+	if utf8.Valid(response.Body) {
+		fixtureResp.Body = string(response.Body)
+	} else {
+		fixtureResp.BodyBase64 = base64.StdEncoding.EncodeToString(response.Body)
+	}
+	return fixtureResp
+}
+
+func newFixtureResponses(responses []TestHTTPResponse) []fixtureResponse {
+	fixtureResps := make([]fixtureResponse, len(responses))
+	for i, response := range responses {
+		fixtureResps[i] = newFixtureResponse(response)
+	}
+	return fixtureResps
+}
+
+func (fixtureResp fixtureResponse) testHTTPResponse() (TestHTTPResponse, error) {
+	response := TestHTTPResponse{Status: fixtureResp.Status, Headers: fixtureResp.Headers, Body: []byte(fixtureResp.Body)}
+	if fixtureResp.BodyBase64 != "" {
+		body, err := base64.StdEncoding.DecodeString(fixtureResp.BodyBase64)
+		if err != nil {
+			return response, err
+		}
+		response.Body = body
+	}
+	if fixtureResp.Delay != "" {
+		delay, err := time.ParseDuration(fixtureResp.Delay)
+		if err != nil {
+			return response, err
+		}
+		response.Delay = delay
+	}
+	if len(fixtureResp.Cookies) > 0 {
+		header := http.Header{"Set-Cookie": fixtureResp.Cookies}
+		response.Cookies = (&http.Response{Header: header}).Cookies()
+		if len(response.Cookies) != len(fixtureResp.Cookies) {
+			return response, fmt.Errorf("Invalid cookie in %q", fixtureResp.Cookies)
+		}
+	}
+	return response, nil
+}
+
+func testHTTPResponses(fixtureResps []fixtureResponse) ([]TestHTTPResponse, error) {
+	responses := make([]TestHTTPResponse, len(fixtureResps))
+	for i, fixtureResp := range fixtureResps {
+		response, err := fixtureResp.testHTTPResponse()
+		if err != nil {
+			return nil, err
+		}
+		responses[i] = response
+	}
+	return responses, nil
+}
+
+// SaveFixture writes all registered responses, including sequences and
+// method-specific responses, to a JSON file at path
+func (mock *MockHTTP) SaveFixture(path string) error {
+	mock.lock.Lock()
+	fix := fixture{Responses: make(map[string]fixtureResponse, len(mock.Responses))}
+	for testUrl, response := range mock.Responses {
+		fix.Responses[testUrl] = newFixtureResponse(response)
+	}
+	for key, stubbed := range mock.stubs {
+		sequence := fixtureSequence{Method: key.Method, URL: key.URL, Responses: newFixtureResponses(stubbed.responses)}
+		if stubbed.afterLast != nil {
+			afterLast := newFixtureResponse(*stubbed.afterLast)
+			sequence.AfterLast = &afterLast
+		}
+		fix.Sequences = append(fix.Sequences, sequence)
+	}
+	mock.lock.Unlock()
+
+	sort.Slice(fix.Sequences, func(i, j int) bool {
+		if fix.Sequences[i].URL != fix.Sequences[j].URL {
+			return fix.Sequences[i].URL < fix.Sequences[j].URL
+		}
+		return fix.Sequences[i].Method < fix.Sequences[j].Method
+	})
+
+	raw, err := json.MarshalIndent(fix, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(raw, '\n'), 0666)
+}
+
+// LoadFixture registers the responses in the JSON file at path, as written by
+// SaveFixture, replacing any registered for the same URLs. Sequences start
+// from their first response.
+func (mock *MockHTTP) LoadFixture(path string) error {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var fix fixture
+	if err := json.Unmarshal(raw, &fix); err != nil {
+		return err
+	}
+
+	responses := make(map[string]TestHTTPResponse, len(fix.Responses))
+	for testUrl, fixtureResp := range fix.Responses {
+		response, err := fixtureResp.testHTTPResponse()
+		if err != nil {
+			return err
+		}
+		responses[testUrl] = response
+	}
+	stubs := make(map[RequestKey]*stub, len(fix.Sequences))
+	for _, sequence := range fix.Sequences {
+		sequenceResps, err := testHTTPResponses(sequence.Responses)
+		if err != nil {
+			return err
+		}
+		stubbed := &stub{responses: sequenceResps}
+		if sequence.AfterLast != nil {
+			afterLast, err := sequence.AfterLast.testHTTPResponse()
+			if err != nil {
+				return err
+			}
+			stubbed.afterLast = &afterLast
+		}
+		stubs[RequestKey{sequence.Method, sequence.URL}] = stubbed
+	}
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	for testUrl, response := range responses {
+		mock.Responses[testUrl] = response
+	}
+	for key, stubbed := range stubs {
+		mock.stubs[key] = stubbed
+	}
+	return nil
+}
+
+// AddTestDataFromFile is like AddTestData, reading the body from the file at
+// filePath. Relative paths are resolved against the directory of the calling
+// source file.
+func (mock *MockHTTP) AddTestDataFromFile(testUrl string, code int, filePath string) error {
+	return mock.addTestDataFromFile(testUrl, code, filePath, 2)
+}
+
+// MustAddTestDataFromFile is like AddTestDataFromFile, but panics if the file
+// cannot be read
+func (mock *MockHTTP) MustAddTestDataFromFile(testUrl string, code int, filePath string) {
+	if err := mock.addTestDataFromFile(testUrl, code, filePath, 2); err != nil {
+		panic(err)
+	}
+}
+
+// Adds test data read from filePath, resolving relative paths against the
+// source file of the caller skip frames up
+func (mock *MockHTTP) addTestDataFromFile(testUrl string, code int, filePath string, skip int) error {
+	if !filepath.IsAbs(filePath) {
+		if _, callerFile, _, ok := runtime.Caller(skip); ok {
+			filePath = filepath.Join(filepath.Dir(callerFile), filePath)
+		}
+	}
+
+	body, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	mock.AddTestData(testUrl, code, body)
+	return nil
+}
@@ -0,0 +1,127 @@
+package testhttp
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+func TestFixtures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	mock := InitMockHTTP()
+	mock.AddTestData("http://example.com/json", http.StatusOK, []byte(`{"ok":true}`))
+	mock.AddTestData("http://example.com/binary", http.StatusOK, []byte{0xff, 0xfe, 0x00})
+	mock.Responses["http://example.com/xml"] = TestHTTPResponse{
+		Status:  http.StatusCreated,
+		Body:    []byte("<ok/>"),
+		Headers: map[string]string{"Content-Type": "application/xml"},
+	}
+	assert.Nil(t, mock.SaveFixture(path))
+	mock.Close()
+
+	raw, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(raw), `"body": "{\"ok\":true}"`))
+
+	loaded := InitMockHTTP()
+	defer loaded.Close()
+	assert.Nil(t, loaded.LoadFixture(path))
+	assert.Equal(t, mock.Responses, loaded.Responses)
+
+	status, body := get(t, loaded, "http://example.com/binary")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, []byte{0xff, 0xfe, 0x00}, body)
+
+	assert.NotNil(t, loaded.LoadFixture(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestFixtureRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	mock := InitMockHTTP()
+	mock.AddTestDataWithDelay("http://example.com/slow", http.StatusOK, []byte(`{}`), 20*time.Millisecond)
+	mock.Responses["http://example.com/login"] = TestHTTPResponse{
+		Status:  http.StatusOK,
+		Cookies: []*http.Cookie{{Name: "session", Value: "abc", Path: "/", HttpOnly: true}},
+	}
+	mock.AddTestDataSequence("http://example.com/job", []TestHTTPResponse{
+		{Status: http.StatusAccepted, Body: []byte(`{"state":"queued"}`)},
+		{Status: http.StatusAccepted, Body: []byte(`{"state":"running"}`), Delay: 10 * time.Millisecond},
+		{Status: http.StatusOK, Body: []byte(`{"state":"done"}`)},
+	}, TestHTTPResponse{Status: http.StatusNotFound})
+	mock.AddTestDataForMethod("DELETE", "http://example.com/job", http.StatusNoContent, nil)
+	assert.Nil(t, mock.SaveFixture(path))
+	mock.Close()
+
+	raw, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.True(t, strings.Contains(string(raw), `"delay": "20ms"`))
+	assert.True(t, strings.Contains(string(raw), `"session=abc; Path=/; HttpOnly"`))
+
+	loaded := InitMockHTTP()
+	defer loaded.Close()
+	assert.Nil(t, loaded.LoadFixture(path))
+	assert.Equal(t, 20*time.Millisecond, loaded.Responses["http://example.com/slow"].Delay)
+
+	start := time.Now()
+	get(t, loaded, "http://example.com/slow")
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+
+	resp, err := loaded.Client.Get("http://example.com/login")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	if assert.Len(t, resp.Cookies(), 1) {
+		assert.Equal(t, "session", resp.Cookies()[0].Name)
+		assert.Equal(t, "abc", resp.Cookies()[0].Value)
+		assert.True(t, resp.Cookies()[0].HttpOnly)
+	}
+
+	for _, expected := range []string{`{"state":"queued"}`, `{"state":"running"}`, `{"state":"done"}`} {
+		status, body := get(t, loaded, "http://example.com/job")
+		assert.Equal(t, expected, string(body))
+		assert.NotEqual(t, http.StatusNotFound, status)
+	}
+	status, _ := get(t, loaded, "http://example.com/job")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	req, _ := http.NewRequest("DELETE", "http://example.com/job", nil)
+	resp, err = loaded.Client.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestAddTestDataFromFile(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	absolute := filepath.Join(t.TempDir(), "users.json")
+	assert.Nil(t, ioutil.WriteFile(absolute, []byte(`{"users":[{"id":1},{"id":2}]}`), 0666))
+	assert.Nil(t, mock.AddTestDataFromFile("http://example.com/absolute", http.StatusOK, absolute))
+
+	// Relative paths are resolved against this file's directory, which is
+	// the working directory of tests
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	relative, err := filepath.Rel(wd, absolute)
+	assert.Nil(t, err)
+	mock.MustAddTestDataFromFile("http://example.com/relative", http.StatusOK, relative)
+
+	for _, testUrl := range []string{"http://example.com/absolute", "http://example.com/relative"} {
+		status, body := get(t, mock, testUrl)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `{"users":[{"id":1},{"id":2}]}`, string(body))
+	}
+
+	assert.NotNil(t, mock.AddTestDataFromFile("http://example.com/missing", http.StatusOK, "missing.json"))
+	assert.Panics(t, func() {
+		mock.MustAddTestDataFromFile("http://example.com/missing", http.StatusOK, "missing.json")
+	})
+}
@@ -0,0 +1,60 @@
+package testhttp
+
+import (
+	"net/http"
+	"testing"
+)
+
+// ReceivedRequest is a request received by the mock server, see LastRequest
+type ReceivedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// Records a request in the history
+func (mock *MockHTTP) recordRequest(r *http.Request, rUrl string, body []byte) {
+	mock.historyLock.Lock()
+	defer mock.historyLock.Unlock()
+	mock.history = append(mock.history, ReceivedRequest{
+		Method: r.Method,
+		URL:    rUrl,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+}
+
+// LastRequest returns the last request received, or nil if none have been
+func (mock *MockHTTP) LastRequest() *ReceivedRequest {
+	mock.historyLock.Lock()
+	defer mock.historyLock.Unlock()
+	if len(mock.history) == 0 {
+		return nil
+	}
+	last := mock.history[len(mock.history)-1]
+	return &last
+}
+
+// RequestsFor returns the requests received for testUrl with any method, in
+// the order received
+func (mock *MockHTTP) RequestsFor(testUrl string) []ReceivedRequest {
+	mock.historyLock.Lock()
+	defer mock.historyLock.Unlock()
+	var requests []ReceivedRequest
+	for _, request := range mock.history {
+		if request.URL == testUrl {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// AssertCalled fails t unless exactly times requests were received for
+// testUrl with any method
+func (mock *MockHTTP) AssertCalled(t testing.TB, testUrl string, times int) {
+	t.Helper()
+	if received := len(mock.RequestsFor(testUrl)); received != times {
+		t.Errorf("Expected %d requests for %s, received %d", times, testUrl, received)
+	}
+}
@@ -1,41 +1,116 @@
 package testhttp
 
 import (
-    "net/http"
-    "net/http/httptest"
-    "net/url"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2" // HTTP/2 server and client
 )
 
 type TestHTTPResponse struct {
-	Status int
-	Body   []byte
+	Status  int
+	Body    []byte
+	Headers map[string]string // Content-Type is detected from Body if not set
+	Cookies []*http.Cookie
+	Delay   time.Duration // time to wait before responding
 }
 
 type MockHTTP struct {
-	Server    *httptest.Server
-    Client    http.Client
+	Server *httptest.Server
+	Client http.Client
 
 	Responses map[string]TestHTTPResponse
+
+	lock           sync.Mutex
+	failureRates   map[string]float64
+	rand           *rand.Rand
+	defaultStatus  int
+	defaultBody    []byte
+	defaultHeaders http.Header
+	requestCounts  map[RequestKey]*int64
+	totalRequests  int64
+	healthPath     string
+	healthy        bool
+	echoResponses  map[string]echoResponse
+	bodyLimits     map[string]int
+	stubs          map[RequestKey]*stub
+	handlers       map[string]func(r *http.Request) TestHTTPResponse
+	patterns       map[string]patternResponse
+	patternCounts  map[RequestKey]*int64 // requests served by each pattern, by method and pattern
+	connErrors     map[string]bool
+	anyQuery       map[string]TestHTTPResponse
+	partitionFrom  int64
+	partitionTo    int64
+	partitionStart time.Time
+	partitionEnd   time.Time
+	globalDelay    time.Duration
+	strict         testing.TB
+
+	historyLock sync.Mutex
+	history     []ReceivedRequest // see LastRequest
 }
 
-func InitMockHTTP() *MockHTTP {
-    var mock MockHTTP
+// Response echoing request headers, see AddEchoHeadersResponse
+type echoResponse struct {
+	status      int
+	headerNames []string
+}
 
-    mock.Responses = make(map[string]TestHTTPResponse)
-    mock.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rUrl := r.URL
-		response, found := mock.Responses[rUrl.String()]
+// Response for URLs matching a regular expression, see AddTestDataWithPattern
+type patternResponse struct {
+	re       *regexp.Regexp
+	response TestHTTPResponse
+}
 
-		if found {
-			w.WriteHeader(response.Status)
-			w.Header().Set("Content-Type", "application/json")
-            w.Write(response.Body)
-		} else {
-			w.WriteHeader(http.StatusNotFound)
-			w.Header().Set("Content-Type", "application/json")
-            w.Write([]byte(""))
-		}
-	}))
+// Responses registered with Stub, AddTestDataForMethod or
+// AddTestDataSequence, returned in order with the last repeated once the
+// sequence is exhausted unless afterLast is set
+type stub struct {
+	responses []TestHTTPResponse
+	next      int
+	afterLast *TestHTTPResponse
+}
+
+// Returns the next response in the sequence, or false if there is none
+func (stubbed *stub) nextResponse() (TestHTTPResponse, bool) {
+	switch {
+	case stubbed.next < len(stubbed.responses):
+		stubbed.next++
+		return stubbed.responses[stubbed.next-1], true
+	case stubbed.afterLast != nil:
+		return *stubbed.afterLast, true
+	case len(stubbed.responses) > 0:
+		return stubbed.responses[len(stubbed.responses)-1], true
+	default:
+		return TestHTTPResponse{}, false
+	}
+}
+
+// RequestKey identifies requests to the mock server by method and URL
+type RequestKey struct {
+	Method string
+	URL    string
+}
+
+func InitMockHTTP() *MockHTTP {
+	mock := newMockHTTP()
+	mock.Server = httptest.NewServer(http.HandlerFunc(mock.serveHTTP))
 
 	transport := &http.Transport{
 		Proxy: func(req *http.Request) (*url.URL, error) {
@@ -45,20 +120,610 @@ func InitMockHTTP() *MockHTTP {
 
 	mock.Client = http.Client{Transport: transport}
 
-    return &mock
+	return mock
+}
+
+// InitMockHTTPS is like InitMockHTTP, but serves HTTPS with a self-signed
+// certificate trusted by Client. Requests for https:// URLs of any host are
+// sent to the mock server, and test data is matched on the full URL.
+func InitMockHTTPS() *MockHTTP {
+	mock := newMockHTTP()
+	mock.Server = httptest.NewTLSServer(http.HandlerFunc(mock.serveHTTP))
+
+	// Trusts the server's certificate, which is issued for example.com
+	transport := mock.Server.Client().Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.ServerName = "example.com"
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, mock.Server.Listener.Addr().String())
+	}
+
+	mock.Client = http.Client{Transport: transport}
+
+	return mock
+}
+
+// InitMockHTTP2 is like InitMockHTTPS, but serves HTTP/2 and Client only
+// speaks HTTP/2. Panics if the server can't be configured for HTTP/2, like
+// httptest does when it can't listen.
+func InitMockHTTP2() *MockHTTP {
+	mock := newMockHTTP()
+	mock.Server = httptest.NewUnstartedServer(http.HandlerFunc(mock.serveHTTP))
+	if err := http2.ConfigureServer(mock.Server.Config, nil); err != nil {
+		panic(fmt.Sprintf("testhttp: failed to configure HTTP/2: %v", err))
+	}
+	mock.Server.TLS = mock.Server.Config.TLSConfig // advertises h2
+	mock.Server.StartTLS()
+
+	// Trusts the server's certificate, which is issued for example.com
+	tlsConfig := &tls.Config{
+		RootCAs:    mock.Server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs,
+		ServerName: "example.com",
+	}
+	transport := &http2.Transport{
+		TLSClientConfig: tlsConfig,
+		DialTLSContext: func(ctx context.Context, network, addr string, config *tls.Config) (net.Conn, error) {
+			dialer := tls.Dialer{Config: config}
+			return dialer.DialContext(ctx, network, mock.Server.Listener.Addr().String())
+		},
+	}
+
+	mock.Client = http.Client{Transport: transport}
+
+	return mock
+}
+
+// Returns a MockHTTP without a server
+func newMockHTTP() *MockHTTP {
+	var mock MockHTTP
+
+	mock.Reset()
+	mock.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	mock.ResetDefaultResponse()
+
+	return &mock
+}
+
+// Returns the URL test data is matched against, the absolute URL of requests
+// through the proxy or over TLS and the path and query of other requests
+func requestURL(r *http.Request) string {
+	if r.TLS != nil {
+		return "https://" + r.Host + r.URL.RequestURI()
+	}
+	return r.URL.String()
+}
+
+func (mock *MockHTTP) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	rUrl := requestURL(r)
+	body, _ := ioutil.ReadAll(r.Body)
+	mock.recordRequest(r, rUrl, body)
+
+	mock.lock.Lock()
+	count, found := mock.requestCounts[RequestKey{r.Method, rUrl}]
+	if !found {
+		count = new(int64)
+		mock.requestCounts[RequestKey{r.Method, rUrl}] = count
+	}
+	failed := false
+	if rate, found := mock.failureRates[rUrl]; found {
+		failed = mock.rand.Float64() < rate
+	}
+	response, found := mock.Responses[rUrl]
+	if path, query, hasQuery := strings.Cut(rUrl, "?"); hasQuery && !found {
+		response, found = mock.Responses[path+"?"+sortQuery(query)]
+		if !found {
+			response, found = mock.anyQuery[path]
+		}
+	} else if !found {
+		response, found = mock.anyQuery[rUrl]
+	}
+	stubbed, isStub := mock.stubs[RequestKey{r.Method, rUrl}]
+	if !isStub {
+		stubbed, isStub = mock.stubs[RequestKey{"", rUrl}] // any method
+	}
+	if isStub {
+		if next, hasNext := stubbed.nextResponse(); hasNext {
+			response, found = next, true
+		}
+	}
+	var patternCount *int64
+	handler, isHandled := mock.handlers[rUrl]
+	if !found {
+		var pattern string
+		response, pattern, found = mock.matchPattern(rUrl)
+		if found && !isHandled {
+			patternCount = mock.patternCounts[RequestKey{r.Method, pattern}]
+			if patternCount == nil {
+				patternCount = new(int64)
+				mock.patternCounts[RequestKey{r.Method, pattern}] = patternCount
+			}
+		}
+	}
+	echo, isEcho := mock.echoResponses[rUrl]
+	connError := mock.connErrors[rUrl]
+	bodyLimit, limited := mock.bodyLimits[rUrl]
+	if !limited {
+		bodyLimit = -1
+	}
+	isHealthCheck := mock.healthPath != "" && r.URL.Path == mock.healthPath
+	healthy := mock.healthy
+	defaultStatus, defaultBody, defaultHeaders := mock.defaultStatus, mock.defaultBody, mock.defaultHeaders
+	partitionFrom, partitionTo := mock.partitionFrom, mock.partitionTo
+	partitionStart, partitionEnd := mock.partitionStart, mock.partitionEnd
+	globalDelay := mock.globalDelay
+	strict := mock.strict
+	mock.lock.Unlock()
+
+	atomic.AddInt64(count, 1)
+	if patternCount != nil {
+		atomic.AddInt64(patternCount, 1)
+	}
+	number := atomic.AddInt64(&mock.totalRequests, 1) - 1
+	now := time.Now()
+	partitioned := connError || (number >= partitionFrom && number < partitionTo) ||
+		(!now.Before(partitionStart) && now.Before(partitionEnd))
+
+	if !partitioned {
+		sleep(r, globalDelay)
+		if isHandled {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			response, found = handler(r), true
+		}
+	}
+	if partitioned {
+		dropConnection(w)
+	} else if isHealthCheck {
+		w.Header().Set("Content-Type", "application/json")
+		if healthy {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"ok"}`))
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"status":"degraded"}`))
+		}
+	} else if failed {
+		writeResponse(w, http.StatusInternalServerError, []byte(""), -1)
+	} else if isEcho {
+		for _, name := range echo.headerNames {
+			if values, found := r.Header[http.CanonicalHeaderKey(name)]; found {
+				w.Header()[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		w.WriteHeader(echo.status)
+	} else if found {
+		sleep(r, response.Delay)
+		for name, value := range response.Headers {
+			w.Header().Set(name, value)
+		}
+		for _, cookie := range response.Cookies {
+			http.SetCookie(w, cookie)
+		}
+		writeResponse(w, response.Status, response.Body, bodyLimit)
+	} else {
+		if strict != nil {
+			strict.Errorf("Unexpected request to unregistered URL: %s %s", r.Method, rUrl)
+		}
+		for name, values := range defaultHeaders {
+			w.Header()[name] = values
+		}
+		writeResponse(w, defaultStatus, defaultBody, bodyLimit)
+	}
+}
+
+// Waits for delay, or until the client of r disconnects
+func sleep(r *http.Request, delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+	}
+}
+
+// Closes the connection without writing a response
+func dropConnection(w http.ResponseWriter) {
+	if hijacker, ok := w.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+			return
+		}
+	}
+	// Aborts the response without logging when the connection can't be hijacked
+	panic(http.ErrAbortHandler)
+}
+
+// Writes status and body, detecting the Content-Type from body unless it
+// has already been set. Bodies longer than a non-negative maxBytes are
+// truncated, while Content-Length still announces the full body.
+func writeResponse(w http.ResponseWriter, status int, body []byte, maxBytes int) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", detectContentType(body))
+	}
+	if maxBytes >= 0 && len(body) > maxBytes {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		body = body[:maxBytes]
+	}
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Returns application/json for empty or valid JSON bodies, which
+// http.DetectContentType reports as plain text, otherwise the detected type
+func detectContentType(body []byte) string {
+	if len(body) == 0 || json.Valid(body) {
+		return "application/json"
+	}
+	return http.DetectContentType(body)
 }
 
 func (mock *MockHTTP) AddTestData(testUrl string, code int, body []byte) {
+	mock.AddTestDataWithHeaders(testUrl, code, nil, body)
+}
+
+// AddTestDataWithHeaders is like AddTestData, setting headers on the response
+func (mock *MockHTTP) AddTestDataWithHeaders(testUrl string, code int, headers map[string]string, body []byte) {
 	var resp TestHTTPResponse
 	resp.Status = code
 	resp.Body = body
+	resp.Headers = headers
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
 	mock.Responses[testUrl] = resp
 }
 
-func (mock *MockHTTP) DeleteTestData(testUrl string) {
-    delete(mock.Responses, testUrl)
+// AddTestDataWithQueryParams is like AddTestData for path with the query
+// string params, which match in any order
+func (mock *MockHTTP) AddTestDataWithQueryParams(path string, params map[string]string, code int, body []byte) {
+	query := make(url.Values, len(params))
+	for name, value := range params {
+		query.Set(name, value)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	mock.AddTestData(path, code, body)
+}
+
+// AddTestDataIgnoreQuery is like AddTestData, matching requests for path with
+// any query string. Test data for the exact URL takes priority.
+func (mock *MockHTTP) AddTestDataIgnoreQuery(path string, code int, body []byte) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.anyQuery[path] = TestHTTPResponse{Status: code, Body: body}
+}
+
+// Returns query with its parameters sorted by name, as encoded by
+// AddTestDataWithQueryParams
+func sortQuery(query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return query
+	}
+	return values.Encode()
+}
+
+// AddTestDataWithDelay is like AddTestData, waiting for delay before
+// responding
+func (mock *MockHTTP) AddTestDataWithDelay(testUrl string, code int, body []byte, delay time.Duration) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.Responses[testUrl] = TestHTTPResponse{Status: code, Body: body, Delay: delay}
+}
+
+// SetGlobalDelay waits for delay before every response, in addition to any
+// delay of the response itself. 0 removes the delay.
+func (mock *MockHTTP) SetGlobalDelay(delay time.Duration) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.globalDelay = delay
+}
+
+// AddTestDataSequence responds to successive requests for testUrl with any
+// method with successive responses. Once all have been returned the last is
+// repeated, or afterLast if given (e.g. a 404). Takes priority over test data
+// added with AddTestData.
+func (mock *MockHTTP) AddTestDataSequence(testUrl string, responses []TestHTTPResponse, afterLast ...TestHTTPResponse) {
+	sequence := &stub{responses: append([]TestHTTPResponse(nil), responses...)}
+	if len(afterLast) > 0 {
+		sequence.afterLast = &afterLast[0]
+	}
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.stubs[RequestKey{"", testUrl}] = sequence
+}
+
+// AddTestDataFunc responds to requests for testUrl with the response
+// returned by fn, which can read the request body. Takes priority over other
+// test data for testUrl.
+func (mock *MockHTTP) AddTestDataFunc(testUrl string, fn func(r *http.Request) TestHTTPResponse) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.handlers[testUrl] = fn
+}
+
+// AddTestDataWithPattern responds to requests for URLs matching the regular
+// expression pattern, e.g. "/users/[0-9]+". Test data for the exact URL takes
+// priority, and the longest pattern wins when several match. Delete with
+// DeleteTestData(pattern). Panics if pattern is not a valid regular expression.
+func (mock *MockHTTP) AddTestDataWithPattern(pattern string, code int, body []byte) {
+	re := regexp.MustCompile(pattern)
+
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.patterns[pattern] = patternResponse{re: re, response: TestHTTPResponse{Status: code, Body: body}}
+}
+
+// Returns the response of the longest pattern matching rUrl along with the
+// pattern. Must be called with the lock held.
+func (mock *MockHTTP) matchPattern(rUrl string) (TestHTTPResponse, string, bool) {
+	var response TestHTTPResponse
+	var matched string
+	longest := -1
+	for pattern, patterned := range mock.patterns {
+		if len(pattern) > longest && patterned.re.MatchString(rUrl) {
+			response, matched, longest = patterned.response, pattern, len(pattern)
+		}
+	}
+	return response, matched, longest >= 0
+}
+
+// AddJSONTestData is like AddTestData, responding with v marshalled to JSON
+func (mock *MockHTTP) AddJSONTestData(testUrl string, code int, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	mock.AddTestDataWithHeaders(testUrl, code, map[string]string{"Content-Type": "application/json"}, body)
+	return nil
+}
+
+// AddJSONTestDataFunc is like AddTestDataFunc, responding with the status
+// and value marshalled to JSON returned by fn. Responds with a 500 and the
+// error as body if the value cannot be marshalled.
+func (mock *MockHTTP) AddJSONTestDataFunc(testUrl string, fn func(r *http.Request) (int, interface{})) {
+	mock.AddTestDataFunc(testUrl, func(r *http.Request) TestHTTPResponse {
+		code, v := fn(r)
+		body, err := json.Marshal(v)
+		if err != nil {
+			return TestHTTPResponse{Status: http.StatusInternalServerError, Body: []byte(err.Error())}
+		}
+		return TestHTTPResponse{Status: code, Body: body, Headers: map[string]string{"Content-Type": "application/json"}}
+	})
+}
+
+// AddTestDataForMethod responds to requests for testUrl with the given
+// method, taking priority over test data added with AddTestData for any method
+func (mock *MockHTTP) AddTestDataForMethod(method, testUrl string, code int, body []byte) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.stubs[RequestKey{method, testUrl}] = &stub{responses: []TestHTTPResponse{{Status: code, Body: body}}}
+}
+
+// DeleteTestData removes the test data for testUrl, or only the responses
+// for method if given
+func (mock *MockHTTP) DeleteTestData(testUrl string, method ...string) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	if len(method) > 0 {
+		delete(mock.stubs, RequestKey{method[0], testUrl})
+		return
+	}
+	delete(mock.Responses, testUrl)
+	delete(mock.echoResponses, testUrl)
+	delete(mock.handlers, testUrl)
+	delete(mock.patterns, testUrl)
+	delete(mock.connErrors, testUrl)
+	delete(mock.anyQuery, testUrl)
+	for key := range mock.stubs {
+		if key.URL == testUrl {
+			delete(mock.stubs, key)
+		}
+	}
+}
+
+// AddConnectionError closes the connection without a response for requests
+// for testUrl
+func (mock *MockHTTP) AddConnectionError(testUrl string) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.connErrors[testUrl] = true
+}
+
+// AddPartialResponse is like AddTestData, but only writes the first
+// truncateAfter bytes of body before closing the connection, so clients
+// reading the body get io.ErrUnexpectedEOF. See SetBodySizeLimit.
+func (mock *MockHTTP) AddPartialResponse(testUrl string, code int, body []byte, truncateAfter int) {
+	mock.AddTestData(testUrl, code, body)
+	mock.SetBodySizeLimit(testUrl, truncateAfter)
+}
+
+// AddEchoHeadersResponse responds to requests for testUrl with status, an
+// empty body and the request's values of headerNames copied to the response
+func (mock *MockHTTP) AddEchoHeadersResponse(testUrl string, status int, headerNames ...string) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.echoResponses[testUrl] = echoResponse{status: status, headerNames: headerNames}
+}
+
+// SetBodySizeLimit truncates response bodies for testUrl to maxBytes while
+// announcing the full length, so clients reading the body get
+// io.ErrUnexpectedEOF. A negative maxBytes removes the limit.
+func (mock *MockHTTP) SetBodySizeLimit(testUrl string, maxBytes int) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	if maxBytes < 0 {
+		delete(mock.bodyLimits, testUrl)
+	} else {
+		mock.bodyLimits[testUrl] = maxBytes
+	}
+}
+
+// SetFailureRate makes requests to testUrl fail with a 500 response with
+// probability rate (0.0 - 1.0). A rate of 0 removes the failure simulation.
+func (mock *MockHTTP) SetFailureRate(testUrl string, rate float64) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	if rate <= 0 {
+		delete(mock.failureRates, testUrl)
+	} else {
+		mock.failureRates[testUrl] = rate
+	}
+}
+
+// SetFailureSeed seeds the random number generator used by SetFailureRate
+// for deterministic tests
+func (mock *MockHTTP) SetFailureSeed(seed int64) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.rand = rand.New(rand.NewSource(seed))
+}
+
+// SetDefaultResponse sets the response returned for requests to URLs without
+// registered test data, replacing the default empty 404
+func (mock *MockHTTP) SetDefaultResponse(code int, body []byte, headers http.Header) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.defaultStatus = code
+	mock.defaultBody = body
+	mock.defaultHeaders = headers
+}
+
+// ResetDefaultResponse restores the default empty 404 response
+func (mock *MockHTTP) ResetDefaultResponse() {
+	mock.SetDefaultResponse(http.StatusNotFound, []byte(""), nil)
+}
+
+// SetStrictMode fails t whenever a URL without test data is requested, in
+// addition to returning the default response
+func (mock *MockHTTP) SetStrictMode(t testing.TB) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.strict = t
+}
+
+// SetHealthEndpoint serves a health check on path (matched regardless of host
+// and query), returning 200 {"status":"ok"} when healthy or 503
+// {"status":"degraded"} otherwise
+func (mock *MockHTTP) SetHealthEndpoint(path string, healthy bool) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.healthPath = path
+	mock.healthy = healthy
+}
+
+// SetHealthy toggles the state reported by the health check endpoint
+func (mock *MockHTTP) SetHealthy(healthy bool) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.healthy = healthy
+}
+
+// PartitionNetwork simulates the server being unreachable by closing the
+// connection without a response for requests numbered in [fromRequest,
+// toRequest), counting every request received from 0. Note that clients
+// retry idempotent requests that fail on a reused connection, which consumes
+// further request numbers. Requests outside the window are handled normally.
+func (mock *MockHTTP) PartitionNetwork(fromRequest, toRequest int) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.partitionFrom = int64(fromRequest)
+	mock.partitionTo = int64(toRequest)
+}
+
+// PartitionByTime simulates the server being unreachable by closing the
+// connection without a response for requests received in [start, end)
+func (mock *MockHTTP) PartitionByTime(start, end time.Time) {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	mock.partitionStart = start
+	mock.partitionEnd = end
+}
+
+// RequestCount returns the number of requests received for testUrl with the
+// given method, or with any method if empty
+func (mock *MockHTTP) RequestCount(method, testUrl string) int {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	return sumCounts(mock.requestCounts, method, testUrl)
+}
+
+// Sums the counts of url with method, or with any method if empty. Must be
+// called with the lock held.
+func sumCounts(counts map[RequestKey]*int64, method, url string) int {
+	total := 0
+	for key, count := range counts {
+		if (method == "" || key.Method == method) && key.URL == url {
+			total += int(atomic.LoadInt64(count))
+		}
+	}
+	return total
+}
+
+// CallCount returns the number of requests received for testUrl with any
+// method, including requests without test data. It is RequestCount with an
+// empty method.
+func (mock *MockHTTP) CallCount(testUrl string) int {
+	return mock.RequestCount("", testUrl)
+}
+
+// AssertURLCalledTimes fails t unless exactly expected requests were received
+// for testUrl with any method
+func (mock *MockHTTP) AssertURLCalledTimes(t testing.TB, testUrl string, expected int) {
+	t.Helper()
+	if count := mock.CallCount(testUrl); count != expected {
+		t.Errorf("Expected %s to be called %d times, was called %d times", testUrl, expected, count)
+	}
+}
+
+// RequestCountForPattern returns the number of requests with the given method
+// (any method if empty) answered by the test data registered with
+// AddTestDataWithPattern(pattern). Requests answered by test data for the
+// exact URL or by a longer matching pattern are not counted.
+func (mock *MockHTTP) RequestCountForPattern(method, pattern string) int {
+	mock.lock.Lock()
+	defer mock.lock.Unlock()
+	return sumCounts(mock.patternCounts, method, pattern)
+}
+
+// TotalRequestCount returns the number of requests received for all URLs
+func (mock *MockHTTP) TotalRequestCount() int {
+	return int(atomic.LoadInt64(&mock.totalRequests))
+}
+
+// Reset removes all per-URL test data and clears the request counts and
+// history, keeping the server running. Server-wide settings such as the
+// default response, delay and strict mode are kept.
+func (mock *MockHTTP) Reset() {
+	mock.lock.Lock()
+	mock.Responses = make(map[string]TestHTTPResponse)
+	mock.failureRates = make(map[string]float64)
+	mock.requestCounts = make(map[RequestKey]*int64)
+	mock.echoResponses = make(map[string]echoResponse)
+	mock.bodyLimits = make(map[string]int)
+	mock.stubs = make(map[RequestKey]*stub)
+	mock.handlers = make(map[string]func(r *http.Request) TestHTTPResponse)
+	mock.patterns = make(map[string]patternResponse)
+	mock.patternCounts = make(map[RequestKey]*int64)
+	mock.connErrors = make(map[string]bool)
+	mock.anyQuery = make(map[string]TestHTTPResponse)
+	atomic.StoreInt64(&mock.totalRequests, 0)
+	mock.lock.Unlock()
+
+	mock.historyLock.Lock()
+	defer mock.historyLock.Unlock()
+	mock.history = nil
+}
+
+// TotalCallCount is an alias of TotalRequestCount, alongside CallCount
+func (mock *MockHTTP) TotalCallCount() int {
+	return mock.TotalRequestCount()
 }
 
 func (mock *MockHTTP) Close() {
-    mock.Server.Close()
+	mock.Server.Close()
 }
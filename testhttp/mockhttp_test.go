@@ -0,0 +1,656 @@
+package testhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert" // Assertion package
+)
+
+// Performs a GET with the mock's client, returning the status code and body
+func get(t *testing.T, mock *MockHTTP, testUrl string) (int, []byte) {
+	resp, err := mock.Client.Get(testUrl)
+	if err != nil {
+		t.Fatalf("Error requesting %s: %s", testUrl, err.Error())
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Error reading response from %s: %s", testUrl, err.Error())
+	}
+	return resp.StatusCode, body
+}
+
+// Records the failures reported to it rather than failing the test
+type failureRecorder struct {
+	testing.TB
+	failures []string
+}
+
+func (recorder *failureRecorder) Errorf(format string, args ...interface{}) {
+	recorder.failures = append(recorder.failures, fmt.Sprintf(format, args...))
+}
+
+func TestAddTestData(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/test", http.StatusOK, []byte(`{"ok":true}`))
+
+	status, body := get(t, mock, "http://example.com/test")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"ok":true}`, string(body))
+
+	mock.DeleteTestData("http://example.com/test")
+	status, _ = get(t, mock, "http://example.com/test")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestSetFailureRate(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/flaky", http.StatusOK, []byte("{}"))
+	mock.SetFailureSeed(42)
+	mock.SetFailureRate("http://example.com/flaky", 0.5)
+
+	failures := 0
+	for i := 0; i < 1000; i++ {
+		if status, _ := get(t, mock, "http://example.com/flaky"); status == http.StatusInternalServerError {
+			failures++
+		}
+	}
+	assert.InDelta(t, 500, failures, 60)
+
+	mock.SetFailureRate("http://example.com/flaky", 0)
+	status, _ := get(t, mock, "http://example.com/flaky")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestSetDefaultResponse(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	headers := http.Header{"X-Error": []string{"true"}}
+	mock.SetDefaultResponse(http.StatusNotFound, []byte(`{"error":"not_found"}`), headers)
+
+	resp, err := mock.Client.Get("http://example.com/missing")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, `{"error":"not_found"}`, string(body))
+	assert.Equal(t, "true", resp.Header.Get("X-Error"))
+
+	mock.ResetDefaultResponse()
+	status, body := get(t, mock, "http://example.com/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "", string(body))
+}
+
+func TestRequestCounts(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/users/1", http.StatusOK, []byte("{}"))
+	mock.AddTestDataWithPattern("/users/[0-9]+$", http.StatusOK, []byte("{}"))
+	mock.AddTestDataWithPattern("/users/", http.StatusOK, []byte("{}"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resp, err := mock.Client.Get("http://example.com/users/" + strconv.Itoa(i%2+1))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}(i)
+	}
+	wg.Wait()
+	resp, err := mock.Client.Post("http://example.com/users/1", "application/json", nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	resp, err = mock.Client.Get("http://example.com/users/me")
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, 10, mock.RequestCount("GET", "http://example.com/users/1"))
+	assert.Equal(t, 10, mock.RequestCount("GET", "http://example.com/users/2"))
+	assert.Equal(t, 1, mock.RequestCount("POST", "http://example.com/users/1"))
+	assert.Equal(t, 0, mock.RequestCount("GET", "http://example.com/users/3"))
+	assert.Equal(t, 11, mock.RequestCount("", "http://example.com/users/1"))
+
+	// Only requests answered by a pattern count, by the longest match
+	assert.Equal(t, 10, mock.RequestCountForPattern("GET", "/users/[0-9]+$"))
+	assert.Equal(t, 0, mock.RequestCountForPattern("POST", "/users/[0-9]+$"))
+	assert.Equal(t, 1, mock.RequestCountForPattern("", "/users/"))
+	assert.Equal(t, 0, mock.RequestCountForPattern("", "[invalid"))
+	assert.Equal(t, 22, mock.TotalRequestCount())
+
+	mock.Reset()
+	assert.Equal(t, 0, mock.RequestCountForPattern("GET", "/users/[0-9]+$"))
+}
+
+func TestAddJSONTestData(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	type user struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name"`
+		Roles []string `json:"roles"`
+	}
+	expected := user{ID: 42, Name: "Ada", Roles: []string{"admin"}}
+	assert.Nil(t, mock.AddJSONTestData("http://example.com/users/42", http.StatusOK, expected))
+	assert.NotNil(t, mock.AddJSONTestData("http://example.com/invalid", http.StatusOK, make(chan int)))
+
+	resp, err := mock.Client.Get("http://example.com/users/42")
+	assert.Nil(t, err)
+	var actual user
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&actual))
+	resp.Body.Close()
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, expected, actual)
+
+	mock.AddJSONTestDataFunc("http://example.com/users", func(r *http.Request) (int, interface{}) {
+		var created user
+		if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+			return http.StatusBadRequest, map[string]string{"error": err.Error()}
+		}
+		created.ID = 7
+		return http.StatusCreated, created
+	})
+
+	resp, err = mock.Client.Post("http://example.com/users", "application/json", strings.NewReader(`{"name":"Grace"}`))
+	assert.Nil(t, err)
+	actual = user{}
+	assert.Nil(t, json.NewDecoder(resp.Body).Decode(&actual))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, user{ID: 7, Name: "Grace"}, actual)
+}
+
+func TestCallCount(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/cached", http.StatusOK, []byte(`{}`))
+	for i := 0; i < 3; i++ {
+		get(t, mock, "http://example.com/cached")
+	}
+	for i := 0; i < 2; i++ {
+		get(t, mock, "http://example.com/unregistered")
+	}
+
+	assert.Equal(t, 3, mock.CallCount("http://example.com/cached"))
+	assert.Equal(t, 2, mock.CallCount("http://example.com/unregistered"))
+	assert.Equal(t, 0, mock.CallCount("http://example.com/other"))
+	assert.Equal(t, 5, mock.TotalCallCount())
+	mock.AssertURLCalledTimes(t, "http://example.com/cached", 3)
+
+	recorder := &failureRecorder{TB: t}
+	mock.AssertURLCalledTimes(recorder, "http://example.com/cached", 1)
+	assert.Equal(t, []string{"Expected http://example.com/cached to be called 1 times, was called 3 times"}, recorder.failures)
+}
+
+func TestSetStrictMode(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	recorder := &failureRecorder{TB: t}
+	mock.SetStrictMode(recorder)
+	mock.AddTestData("http://example.com/registered", http.StatusOK, []byte(`{}`))
+
+	status, _ := get(t, mock, "http://example.com/registered")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Empty(t, recorder.failures)
+
+	status, _ = get(t, mock, "http://example.com/unregistered")
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, []string{"Unexpected request to unregistered URL: GET http://example.com/unregistered"}, recorder.failures)
+}
+
+func TestHealthEndpoint(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.SetHealthEndpoint("/health", true)
+	status, body := get(t, mock, "http://example.com/health")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `{"status":"ok"}`, string(body))
+
+	mock.SetHealthy(false)
+	status, body = get(t, mock, "http://example.com/health")
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, `{"status":"degraded"}`, string(body))
+}
+
+func TestAddEchoHeadersResponse(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddEchoHeadersResponse("http://example.com/echo", http.StatusAccepted, "X-Request-ID", "x-tenant-id")
+
+	req, _ := http.NewRequest("GET", "http://example.com/echo", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	req.Header.Set("X-Tenant-ID", "tenant")
+	req.Header.Set("Authorization", "secret")
+	resp, err := mock.Client.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, "abc-123", resp.Header.Get("X-Request-ID"))
+	assert.Equal(t, "tenant", resp.Header.Get("X-Tenant-ID"))
+	assert.Equal(t, "", resp.Header.Get("Authorization"))
+}
+
+func TestContentTypeDetection(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/json", http.StatusOK, []byte(`{"ok":true}`))
+	mock.AddTestData("http://example.com/html", http.StatusOK, []byte("<html><body>hello</body></html>"))
+	mock.AddTestData("http://example.com/text", http.StatusOK, []byte("plain text"))
+	mock.Responses["http://example.com/explicit"] = TestHTTPResponse{
+		Status:  http.StatusOK,
+		Body:    []byte("plain text"),
+		Headers: map[string]string{"Content-Type": "application/xml"},
+	}
+
+	expected := map[string]string{
+		"http://example.com/json":     "application/json",
+		"http://example.com/html":     "text/html; charset=utf-8",
+		"http://example.com/text":     "text/plain; charset=utf-8",
+		"http://example.com/explicit": "application/xml",
+	}
+	for testUrl, contentType := range expected {
+		resp, err := mock.Client.Get(testUrl)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		assert.Equal(t, contentType, resp.Header.Get("Content-Type"), testUrl)
+	}
+}
+
+func TestAddTestDataWithHeaders(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	headers := map[string]string{"Content-Type": "application/xml", "X-Custom-Header": "test"}
+	mock.AddTestDataWithHeaders("http://example.com/xml", http.StatusOK, headers, []byte("<ok/>"))
+
+	resp, err := mock.Client.Get("http://example.com/xml")
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/xml", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "test", resp.Header.Get("X-Custom-Header"))
+}
+
+func TestAddTestDataFunc(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/echo", http.StatusNotFound, nil)
+	mock.AddTestDataFunc("http://example.com/echo", func(r *http.Request) TestHTTPResponse {
+		body, _ := ioutil.ReadAll(r.Body)
+		return TestHTTPResponse{Status: http.StatusOK, Body: body}
+	})
+
+	resp, err := mock.Client.Post("http://example.com/echo", "application/json", strings.NewReader(`{"id":42}`))
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"id":42}`, string(body))
+	assert.Equal(t, `{"id":42}`, string(mock.LastRequest().Body))
+}
+
+func TestSetBodySizeLimit(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/large", http.StatusOK, []byte(`{"data":"0123456789"}`))
+	mock.SetBodySizeLimit("http://example.com/large", 5)
+
+	resp, err := mock.Client.Get("http://example.com/large")
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, `{"dat`, string(body))
+
+	mock.SetBodySizeLimit("http://example.com/large", -1)
+	_, body = get(t, mock, "http://example.com/large")
+	assert.Equal(t, `{"data":"0123456789"}`, string(body))
+}
+
+func TestConnectionErrors(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddConnectionError("http://example.com/refused")
+	mock.AddPartialResponse("http://example.com/partial", http.StatusOK, []byte(`{"data":"0123456789"}`), 8)
+
+	_, err := mock.Client.Get("http://example.com/refused")
+	assert.NotNil(t, err)
+
+	resp, err := mock.Client.Get("http://example.com/partial")
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, `{"data":`, string(body))
+}
+
+func TestPartitionNetwork(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/orders", http.StatusOK, []byte("{}"))
+	mock.PartitionNetwork(1, 3)
+
+	// POST requests are not retried by the client, so each uses one request number
+	var errs []bool
+	for i := 0; i < 4; i++ {
+		resp, err := mock.Client.Post("http://example.com/orders", "application/json", nil)
+		if err == nil {
+			resp.Body.Close()
+		}
+		errs = append(errs, err != nil)
+	}
+	assert.Equal(t, []bool{false, true, true, false}, errs)
+}
+
+func TestPartitionByTime(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/orders", http.StatusOK, []byte("{}"))
+	mock.PartitionByTime(time.Now(), time.Now().Add(time.Hour))
+	_, err := mock.Client.Post("http://example.com/orders", "application/json", nil)
+	assert.NotNil(t, err)
+
+	mock.PartitionByTime(time.Time{}, time.Time{})
+	resp, err := mock.Client.Post("http://example.com/orders", "application/json", nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestAddTestDataForMethod(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestData("http://example.com/users", http.StatusOK, []byte(`[]`))
+	mock.AddTestDataForMethod("POST", "http://example.com/users", http.StatusCreated, []byte(`{"id":1}`))
+
+	status, body := get(t, mock, "http://example.com/users")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `[]`, string(body))
+	resp, err := mock.Client.Post("http://example.com/users", "application/json", nil)
+	assert.Nil(t, err)
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, `{"id":1}`, string(body))
+
+	// Deleting the POST response falls back to the response for any method
+	mock.DeleteTestData("http://example.com/users", "POST")
+	resp, err = mock.Client.Post("http://example.com/users", "application/json", nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestAddTestDataWithQueryParams(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	params := map[string]string{"q": "go test", "page": "2", "sort": "asc"}
+	mock.AddTestDataWithQueryParams("http://example.com/search", params, http.StatusOK, []byte(`{"page":2}`))
+
+	for _, testUrl := range []string{
+		"http://example.com/search?page=2&q=go+test&sort=asc",
+		"http://example.com/search?sort=asc&q=go%20test&page=2",
+	} {
+		status, body := get(t, mock, testUrl)
+		assert.Equal(t, http.StatusOK, status, testUrl)
+		assert.Equal(t, `{"page":2}`, string(body), testUrl)
+	}
+	for _, testUrl := range []string{
+		"http://example.com/search?page=3&q=go+test&sort=asc",
+		"http://example.com/search?page=2&q=go+test",
+		"http://example.com/search",
+	} {
+		status, _ := get(t, mock, testUrl)
+		assert.Equal(t, http.StatusNotFound, status, testUrl)
+	}
+}
+
+func TestAddTestDataIgnoreQuery(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestDataIgnoreQuery("http://example.com/search", http.StatusOK, []byte(`{"any":true}`))
+	mock.AddTestData("http://example.com/search?q=exact", http.StatusOK, []byte(`{"exact":true}`))
+
+	for _, testUrl := range []string{
+		"http://example.com/search",
+		"http://example.com/search?q=go",
+		"http://example.com/search?page=1&q=other",
+	} {
+		status, body := get(t, mock, testUrl)
+		assert.Equal(t, http.StatusOK, status, testUrl)
+		assert.Equal(t, `{"any":true}`, string(body), testUrl)
+	}
+	_, body := get(t, mock, "http://example.com/search?q=exact")
+	assert.Equal(t, `{"exact":true}`, string(body))
+	status, _ := get(t, mock, "http://example.com/searches?q=go")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	mock.DeleteTestData("http://example.com/search")
+	status, _ = get(t, mock, "http://example.com/search?q=go")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAddTestDataWithPattern(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestDataWithPattern("/users/[0-9]+", http.StatusOK, []byte(`{"user":true}`))
+	mock.AddTestDataWithPattern("/users/[0-9]+/posts", http.StatusOK, []byte(`{"posts":true}`))
+	mock.AddTestData("http://example.com/users/7", http.StatusOK, []byte(`{"exact":true}`))
+
+	for _, testUrl := range []string{"http://example.com/users/1", "http://example.com/users/42"} {
+		status, body := get(t, mock, testUrl)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, `{"user":true}`, string(body))
+	}
+	_, body := get(t, mock, "http://example.com/users/42/posts")
+	assert.Equal(t, `{"posts":true}`, string(body))
+	_, body = get(t, mock, "http://example.com/users/7")
+	assert.Equal(t, `{"exact":true}`, string(body))
+
+	mock.DeleteTestData("/users/[0-9]+")
+	status, _ := get(t, mock, "http://example.com/users/1")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestReset(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	tests := []struct {
+		name    string
+		testUrl string
+		body    string
+	}{
+		{"first", "http://example.com/first", `{"test":1}`},
+		{"second", "http://example.com/second", `{"test":2}`},
+		{"third", "http://example.com/third", `{"test":3}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock.Reset()
+			assert.Empty(t, mock.Responses)
+			assert.Equal(t, 0, mock.TotalCallCount())
+			assert.Nil(t, mock.LastRequest())
+
+			mock.AddTestData(test.testUrl, http.StatusOK, []byte(test.body))
+			for _, other := range tests {
+				status, body := get(t, mock, other.testUrl)
+				if other.testUrl == test.testUrl {
+					assert.Equal(t, http.StatusOK, status)
+					assert.Equal(t, test.body, string(body))
+				} else {
+					assert.Equal(t, http.StatusNotFound, status, other.testUrl)
+				}
+			}
+			assert.Equal(t, len(tests), mock.TotalCallCount())
+			mock.AssertURLCalledTimes(t, test.testUrl, 1)
+		})
+	}
+}
+
+func TestRequestHistory(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+	assert.Nil(t, mock.LastRequest())
+
+	mock.AddTestData("http://example.com/users", http.StatusOK, []byte("{}"))
+	get(t, mock, "http://example.com/users")
+	get(t, mock, "http://example.com/other")
+	req, _ := http.NewRequest("POST", "http://example.com/users", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("X-Request-ID", "abc")
+	resp, err := mock.Client.Do(req)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	mock.AssertCalled(t, "http://example.com/users", 2)
+	mock.AssertCalled(t, "http://example.com/other", 1)
+	last := mock.LastRequest()
+	assert.Equal(t, "POST", last.Method)
+	assert.Equal(t, "http://example.com/users", last.URL)
+	assert.Equal(t, "abc", last.Header.Get("X-Request-ID"))
+	assert.Equal(t, `{"name":"a"}`, string(last.Body))
+	requests := mock.RequestsFor("http://example.com/users")
+	assert.Equal(t, "GET", requests[0].Method)
+
+	recorder := &failureRecorder{TB: t}
+	mock.AssertCalled(recorder, "http://example.com/users", 3)
+	assert.Equal(t, []string{"Expected 3 requests for http://example.com/users, received 2"}, recorder.failures)
+
+	mock.Reset()
+	assert.Nil(t, mock.LastRequest())
+	mock.AssertCalled(t, "http://example.com/users", 0)
+}
+
+func TestResponseDelay(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestDataWithDelay("http://example.com/slow", http.StatusOK, []byte("{}"), 50*time.Millisecond)
+	mock.AddTestData("http://example.com/fast", http.StatusOK, []byte("{}"))
+
+	start := time.Now()
+	status, _ := get(t, mock, "http://example.com/slow")
+	assert.Equal(t, http.StatusOK, status)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+
+	// Requests time out once the global delay exceeds the client's deadline
+	mock.SetGlobalDelay(time.Second)
+	client := mock.Client
+	client.Timeout = 20 * time.Millisecond
+	_, err := client.Get("http://example.com/fast")
+	if assert.NotNil(t, err) {
+		urlErr, ok := err.(*url.Error)
+		assert.True(t, ok && urlErr.Timeout(), err.Error())
+	}
+
+	mock.SetGlobalDelay(0)
+	status, _ = get(t, mock, "http://example.com/fast")
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestAddTestDataSequence(t *testing.T) {
+	mock := InitMockHTTP()
+	defer mock.Close()
+
+	mock.AddTestDataSequence("http://example.com/retry", []TestHTTPResponse{
+		{Status: http.StatusInternalServerError},
+		{Status: http.StatusInternalServerError},
+		{Status: http.StatusOK, Body: []byte(`{"ok":true}`)},
+	})
+	var statuses []int
+	for i := 0; i < 4; i++ {
+		status, _ := get(t, mock, "http://example.com/retry")
+		statuses = append(statuses, status)
+	}
+	assert.Equal(t, []int{500, 500, 200, 200}, statuses)
+
+	mock.AddTestDataSequence("http://example.com/once", []TestHTTPResponse{{Status: http.StatusOK}}, TestHTTPResponse{Status: http.StatusNotFound})
+	status, _ := get(t, mock, "http://example.com/once")
+	assert.Equal(t, http.StatusOK, status)
+	status, _ = get(t, mock, "http://example.com/once")
+	assert.Equal(t, http.StatusNotFound, status)
+
+	// Concurrent requests each get a distinct response
+	mock.AddTestDataSequence("http://example.com/concurrent", []TestHTTPResponse{
+		{Status: 201}, {Status: 202}, {Status: 203}, {Status: 204},
+	}, TestHTTPResponse{Status: http.StatusGone})
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	seen := map[int]int{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _ := get(t, mock, "http://example.com/concurrent")
+			lock.Lock()
+			seen[status]++
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, map[int]int{201: 1, 202: 1, 203: 1, 204: 1}, seen)
+}
+
+func TestInitMockHTTPS(t *testing.T) {
+	mock := InitMockHTTPS()
+	defer mock.Close()
+
+	mock.AddTestData("https://api.example.org/users", http.StatusOK, []byte(`[]`))
+	status, body := get(t, mock, "https://api.example.org/users")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, `[]`, string(body))
+	assert.Equal(t, "https://api.example.org/users", mock.LastRequest().URL)
+
+	status, _ = get(t, mock, "https://api.example.org/missing")
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestInitMockHTTP2(t *testing.T) {
+	mock := InitMockHTTP2()
+	defer mock.Close()
+
+	mock.AddTestData("https://api.example.org/users", http.StatusOK, []byte(`[]`))
+	resp, err := mock.Client.Get("https://api.example.org/users")
+	assert.Nil(t, err)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "HTTP/2.0", resp.Proto)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `[]`, string(body))
+	assert.Equal(t, "https://api.example.org/users", mock.LastRequest().URL)
+}